@@ -2,12 +2,40 @@ package main
 
 import (
 	"log"
+	"os"
 	"combine-worker/internal"
+
+	"github.com/joho/godotenv"
 )
 
 func main() {
 	log.Println("Combine Worker Started")
 
+	// Load .env from possible locations
+	paths := []string{
+		".env",
+		"../.env",
+		"../../.env",
+		"/Users/krishna/Personal Project/pdf/workers/combine-worker/.env",
+	}
+
+	loaded := false
+	for _, p := range paths {
+		if err := godotenv.Load(p); err == nil {
+			log.Println("Loaded .env from:", p)
+			loaded = true
+			break
+		}
+	}
+
+	if !loaded {
+		log.Println("⚠️ WARNING: No .env file loaded!")
+	}
+
+	// print ENV
+	log.Println("COMBINE_QUEUE_URL =", os.Getenv("COMBINE_QUEUE_URL"))
+	log.Println("AWS_S3_BUCKET =", os.Getenv("AWS_S3_BUCKET"))
+
 	internal.InitSQS()
 	internal.InitS3()
 	internal.InitRedis()
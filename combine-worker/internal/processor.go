@@ -0,0 +1,231 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+var officeExts = map[string]bool{
+	".doc": true, ".docx": true, ".odt": true, ".rtf": true, ".txt": true,
+	".xls": true, ".xlsx": true, ".ods": true, ".csv": true,
+	".ppt": true, ".pptx": true, ".odp": true,
+}
+
+var imageExts = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true,
+	".bmp": true, ".tif": true, ".tiff": true, ".webp": true,
+}
+
+// ----------------------------
+// OFFICE → PDF (LibreOffice)
+// ----------------------------
+func officeToPDF(input, outDir string) (string, error) {
+	log.Println("🚀 LibreOffice converting:", input)
+
+	cmd := exec.Command("soffice",
+		"--headless",
+		"--invisible",
+		"--nodefault",
+		"--nofirststartwizard",
+		"--nologo",
+		"--convert-to", "pdf",
+		"--outdir", outDir,
+		input,
+	)
+
+	outBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Println("❌ LibreOffice failed:", err)
+		log.Println("Output:", string(outBytes))
+		return "", err
+	}
+
+	// LibreOffice keeps the base name and swaps the extension
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	out := filepath.Join(outDir, base+".pdf")
+
+	if _, err := os.Stat(out); err != nil {
+		return "", errors.New("LibreOffice output not found: " + out)
+	}
+
+	return out, nil
+}
+
+// ----------------------------
+// IMAGE → PDF (ImageMagick)
+// ----------------------------
+func imageToPDF(input, outDir string) (string, error) {
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	out := filepath.Join(outDir, base+".pdf")
+
+	// every frame of a multi-page TIFF becomes a page; the frames of an
+	// animated GIF are animation steps, so only the first is kept
+	src := input
+	if strings.EqualFold(filepath.Ext(input), ".gif") {
+		src += "[0]"
+	}
+	cmd := exec.Command("convert", src, "-auto-orient", out)
+
+	outBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		log.Println("❌ Image to PDF failed:", err)
+		log.Println("Output:", string(outBytes))
+		return "", err
+	}
+
+	return out, nil
+}
+
+// ----------------------------
+// ANY SUPPORTED INPUT → PDF
+// ----------------------------
+func convertToPDF(input, outDir string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(input))
+
+	switch {
+	case ext == ".pdf":
+		return input, nil
+
+	case officeExts[ext]:
+		return officeToPDF(input, outDir)
+
+	case imageExts[ext]:
+		return imageToPDF(input, outDir)
+	}
+
+	return "", errors.New("unsupported file type: " + ext)
+}
+
+// ----------------------------
+// PAGE RANGE SELECTION
+// ----------------------------
+// Keeps only the pages in `ranges` (pdfcpu syntax, e.g. "1-3,5,8-"),
+// in the order they are listed.
+func selectPages(input, ranges, outDir string) (string, error) {
+	selected, err := api.ParsePageSelection(strings.ReplaceAll(ranges, " ", ""))
+	if err != nil {
+		return "", err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input))
+	out := filepath.Join(outDir, base+"_pages.pdf")
+
+	if err := api.CollectFile(input, out, selected, nil); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// ----------------------------
+// COMBINE (convert + merge)
+// ----------------------------
+// Per-input page ranges are passed as options keyed by the file's
+// index in Job.Files: pages_0, pages_1, ...
+func combineFiles(files []string, opts map[string]string) (string, error) {
+	workDir, err := os.MkdirTemp("/tmp", "combine_")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	var pdfs []string
+
+	for i, f := range files {
+		// each input gets its own folder so equal base names can't collide
+		fileDir := filepath.Join(workDir, fmt.Sprintf("%03d", i))
+		os.MkdirAll(fileDir, 0755)
+
+		pdf, err := convertToPDF(f, fileDir)
+		if err != nil {
+			return "", fmt.Errorf("file %d (%s): %w", i, filepath.Base(f), err)
+		}
+
+		if ranges := opts[fmt.Sprintf("pages_%d", i)]; ranges != "" {
+			pdf, err = selectPages(pdf, ranges, fileDir)
+			if err != nil {
+				return "", fmt.Errorf("file %d pages %q: %w", i, ranges, err)
+			}
+		}
+
+		pdfs = append(pdfs, pdf)
+	}
+
+	out := TempName("combined", ".pdf")
+
+	if len(pdfs) == 1 {
+		// nothing to merge, just move the single PDF out of the work dir
+		data, err := os.ReadFile(pdfs[0])
+		if err != nil {
+			return "", err
+		}
+		return out, os.WriteFile(out, data, 0644)
+	}
+
+	if err := api.MergeCreateFile(pdfs, out, false, nil); err != nil {
+		return "", err
+	}
+
+	return out, nil
+}
+
+// ----------------------------
+// MAIN PROCESSOR
+// ----------------------------
+func ProcessJob(job Job) {
+	log.Println("⚙ Processing combine job:", job.ID, "files:", len(job.Files))
+	UpdateStatus(job.ID, "processing")
+
+	if len(job.Files) == 0 {
+		failJob(job.ID, errors.New("combine job has no files"))
+		return
+	}
+
+	// 1. Download all inputs
+	var local []string
+	defer func() {
+		for _, p := range local {
+			DeleteFile(p)
+		}
+	}()
+
+	for _, f := range job.Files {
+		p := DownloadFromS3(f)
+		if p == "" {
+			failJob(job.ID, fmt.Errorf("download failed: %s", f))
+			return
+		}
+		local = append(local, p)
+	}
+
+	// 2. Convert + merge
+	out, err := combineFiles(local, job.Options)
+	if err != nil {
+		failJob(job.ID, err)
+		return
+	}
+	defer DeleteFile(out)
+
+	// 3. Upload
+	url := UploadToS3(out)
+	if url == "" {
+		failJob(job.ID, errors.New("upload failed"))
+		return
+	}
+
+	SaveResult(job.ID, url)
+	log.Println("✅ Combine job completed:", job.ID)
+}
+
+// Marks the job failed and stores the reason for the frontend
+func failJob(jobID string, err error) {
+	log.Println("❌ Combine job failed:", jobID, err)
+	SaveError(jobID, "error", err.Error())
+}
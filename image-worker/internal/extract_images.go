@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// One extracted image, as listed in the report
+type extractedImage struct {
+	Page   int    `json:"page"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+	Color  string `json:"color"`
+	DPI    int    `json:"dpi,omitempty"`
+	Size   int64  `json:"size"`
+	File   string `json:"file"`
+}
+
+type extractImagesReport struct {
+	Images  []extractedImage `json:"images"`
+	Skipped int              `json:"skipped"`
+}
+
+// A row of `pdfimages -list`:
+// page num type width height color comp bpc enc interp object ID x-ppi y-ppi size ratio
+type pdfImageEntry struct {
+	Page, Num     int
+	Type          string
+	Width, Height int
+	Color         string
+	Encoding      string
+	XPPI          int
+}
+
+func listPDFImages(input string) ([]pdfImageEntry, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("pdfimages", "-list", input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdfimages -list failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var entries []pdfImageEntry
+	sc := bufio.NewScanner(&stdout)
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 14 {
+			continue
+		}
+		page, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue // header and separator line
+		}
+		num, _ := strconv.Atoi(f[1])
+		w, _ := strconv.Atoi(f[3])
+		h, _ := strconv.Atoi(f[4])
+		ppi, _ := strconv.Atoi(f[12])
+
+		entries = append(entries, pdfImageEntry{
+			Page: page, Num: num, Type: f[2], Width: w, Height: h,
+			Color: f[5], Encoding: f[8], XPPI: ppi,
+		})
+	}
+
+	return entries, nil
+}
+
+// "100" (both sides) or "100x80"; 0 means no filter
+func parseMinSize(s string) (int, int, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" {
+		return 0, 0, nil
+	}
+
+	parts := strings.SplitN(s, "x", 2)
+	w, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || w < 0 {
+		return 0, 0, fmt.Errorf("invalid minSize %q", s)
+	}
+	h := w
+	if len(parts) == 2 {
+		h, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || h < 0 {
+			return 0, 0, fmt.Errorf("invalid minSize %q", s)
+		}
+	}
+	return w, h, nil
+}
+
+// ----------------------------
+// Extract embedded images (native encoding)
+// ----------------------------
+// pdfimages writes JPEG and JPEG 2000 data as stored in the PDF; other
+// images are decoded losslessly to PNG (TIFF for CMYK). Masks and soft
+// masks are alpha channels of other images and are left out. All images
+// are returned in one ZIP, with page and size per image in the report.
+// A PDF without images (or none above minSize) gives no ZIP and an
+// empty report.
+//
+// opts["minSize"] skip images smaller than this, in pixels: "100" or "100x80"
+func extractImages(input string, opts map[string]string) (string, *extractImagesReport) {
+	minW, minH, err := parseMinSize(opts["minSize"])
+	if err != nil {
+		log.Println("❌ Extract images:", err)
+		return "", nil
+	}
+
+	entries, err := listPDFImages(input)
+	if err != nil {
+		log.Println("❌ Extract images:", err)
+		return "", nil
+	}
+
+	dir, err := os.MkdirTemp("/tmp", "extract_images_")
+	if err != nil {
+		log.Println("❌ Extract images:", err)
+		return "", nil
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command("pdfimages", "-j", "-jp2", "-png", "-tiff", input, filepath.Join(dir, "img"))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		log.Println("❌ pdfimages failed:", err, strings.TrimSpace(string(out)))
+		return "", nil
+	}
+
+	// img-007.jpg → 7
+	files := map[int]string{}
+	written, _ := filepath.Glob(filepath.Join(dir, "img-*"))
+	for _, p := range written {
+		base := strings.TrimPrefix(filepath.Base(p), "img-")
+		num, err := strconv.Atoi(strings.TrimSuffix(base, filepath.Ext(base)))
+		if err == nil {
+			files[num] = p
+		}
+	}
+
+	report := &extractImagesReport{Images: []extractedImage{}}
+
+	output := TempFile("extracted_images", ".zip")
+	zf, err := os.Create(output)
+	if err != nil {
+		log.Println("❌ Extract images:", err)
+		return "", nil
+	}
+	zw := zip.NewWriter(zf)
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Num < entries[j].Num })
+	perPage := map[int]int{}
+
+	for _, e := range entries {
+		if e.Type != "image" {
+			continue
+		}
+		src, ok := files[e.Num]
+		if !ok {
+			continue
+		}
+		if e.Width < minW || e.Height < minH {
+			report.Skipped++
+			continue
+		}
+
+		perPage[e.Page]++
+		ext := filepath.Ext(src)
+		name := fmt.Sprintf("page-%d-%d%s", e.Page, perPage[e.Page], ext)
+
+		size, err := addFileToZip(zw, src, name)
+		if err != nil {
+			zw.Close()
+			zf.Close()
+			DeleteFile(output)
+			log.Println("❌ Extract images:", err)
+			return "", nil
+		}
+
+		report.Images = append(report.Images, extractedImage{
+			Page:   e.Page,
+			Width:  e.Width,
+			Height: e.Height,
+			Format: strings.TrimPrefix(ext, "."),
+			Color:  e.Color,
+			DPI:    e.XPPI,
+			Size:   size,
+			File:   name,
+		})
+	}
+
+	err = zw.Close()
+	zf.Close()
+	if err != nil {
+		DeleteFile(output)
+		log.Println("❌ Extract images:", err)
+		return "", nil
+	}
+
+	// nothing to extract is an answer, not a failure: no ZIP, empty report
+	if len(report.Images) == 0 {
+		DeleteFile(output)
+		log.Println("✅ No images to extract (skipped", report.Skipped, "below minSize)")
+		return "", report
+	}
+
+	log.Println("✅ Images extracted:", len(report.Images), "skipped:", report.Skipped)
+	return output, report
+}
+
+// Images are already compressed, so they are stored as is
+func addFileToZip(zw *zip.Writer, path, name string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+	if err != nil {
+		return 0, err
+	}
+	return io.Copy(w, f)
+}
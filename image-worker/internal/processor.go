@@ -54,6 +54,9 @@ func ProcessJob(job Job) {
 
 	var output string
 
+	// optional JSON report saved alongside the result URL
+	var report interface{}
+
 	switch job.Tool {
 
 	case "jpg-to-pdf", "png-to-pdf":
@@ -62,12 +65,28 @@ func ProcessJob(job Job) {
 	case "pdf-to-jpg", "pdf-to-png":
 		output = pdfToImages(local)
 
+	case "extract-images":
+		out, images := extractImages(local, job.Options)
+		output = out
+		if images != nil {
+			report = images
+		}
+
 	default:
 		log.Println("❌ Unknown image tool:", job.Tool)
 		UpdateStatus(job.ID, "error")
 		return
 	}
 
+	// report only (e.g. no images to extract): completed with an empty result
+	if output == "" && report != nil {
+		SaveReport(job.ID, report)
+		SaveResult(job.ID, "")
+		DeleteFile(local)
+		log.Println("✅ Image job completed (report only):", job.ID)
+		return
+	}
+
 	if output == "" {
 		UpdateStatus(job.ID, "error")
 		return
@@ -75,6 +94,9 @@ func ProcessJob(job Job) {
 
 	// Upload output to S3
 	finalURL := UploadToS3(output)
+	if report != nil {
+		SaveReport(job.ID, report)
+	}
 	SaveResult(job.ID, finalURL)
 
 	// Cleanup
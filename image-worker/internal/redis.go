@@ -2,6 +2,7 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"os"
 
@@ -41,3 +42,14 @@ func SaveResult(jobID, url string) {
 	client.Set(ctx, "result:"+jobID, url, 0)
 	client.Set(ctx, "job:"+jobID, "completed", 0)
 }
+
+// Tools that describe their output store a JSON report next to the
+// result: report:<id>
+func SaveReport(jobID string, report interface{}) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		log.Println("❌ Report encode error:", err)
+		return
+	}
+	client.Set(ctx, "report:"+jobID, string(b), 0)
+}
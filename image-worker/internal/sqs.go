@@ -18,9 +18,10 @@ func InitSQS() {
 }
 
 type Job struct {
-	ID    string   `json:"id"`
-	Tool  string   `json:"tool"`
-	Files []string `json:"files"`
+	ID      string            `json:"id"`
+	Tool    string            `json:"tool"`
+	Files   []string          `json:"files"`
+	Options map[string]string `json:"options"`
 }
 
 func ListenToQueue() {
@@ -0,0 +1,317 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// One embedded file, as listed by detach
+type attachmentInfo struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Size         int64  `json:"size"`
+	Modified     string `json:"modified,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+	File         string `json:"file,omitempty"`
+}
+
+type detachReport struct {
+	Attachments []attachmentInfo `json:"attachments"`
+}
+
+// AFRelationship values of PDF 2.0 / PDF/A-3; Factur-X and ZUGFeRD use
+// Data or Alternative for the invoice XML
+var afRelationships = map[string]string{
+	"data":        "Data",
+	"source":      "Source",
+	"alternative": "Alternative",
+	"supplement":  "Supplement",
+	"unspecified": "Unspecified",
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// File names come from inside the PDF or from upload keys; keep them
+// to one safe path component so they can't escape /tmp or break URLs.
+func safeFileName(name, fallback string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+// The file spec dictionary of an embedded file, by name
+func attachmentFileSpec(ctx *model.Context, name string) (types.Dict, error) {
+	tree := ctx.Names["EmbeddedFiles"]
+	if tree == nil {
+		return nil, fmt.Errorf("no embedded files")
+	}
+	o, ok := tree.Value(name)
+	if !ok {
+		return nil, fmt.Errorf("embedded file %q not found", name)
+	}
+	return ctx.DereferenceDict(o)
+}
+
+// ----------------------------
+// ATTACH
+// ----------------------------
+// Marks the file spec as an associated file of the document (catalog
+// /AF), which PDF/A-3 based invoices require, and records the MIME type
+// on the embedded stream.
+func associateFile(ctx *model.Context, name, relationship string) error {
+	fs, err := attachmentFileSpec(ctx, name)
+	if err != nil {
+		return err
+	}
+	fs["AFRelationship"] = types.Name(relationship)
+
+	if ef, err := ctx.DereferenceDict(fs["EF"]); err == nil && ef != nil {
+		if ir, ok := ef["F"].(types.IndirectRef); ok {
+			if sd, _, err := ctx.DereferenceStreamDict(ir); err == nil && sd != nil {
+				if mt := mime.TypeByExtension(filepath.Ext(name)); mt != "" {
+					sd.Dict["Subtype"] = types.Name(strings.Split(mt, ";")[0])
+				}
+			}
+		}
+	}
+
+	o, ok := ctx.Names["EmbeddedFiles"].Value(name)
+	if !ok {
+		return fmt.Errorf("embedded file %q not found", name)
+	}
+	ir, ok := o.(types.IndirectRef)
+	if !ok {
+		return fmt.Errorf("embedded file %q: file spec is not an indirect object", name)
+	}
+
+	af, _ := ctx.DereferenceArray(ctx.RootDict["AF"])
+	for _, e := range af {
+		if r, ok := e.(types.IndirectRef); ok && r.ObjectNumber == ir.ObjectNumber {
+			return nil
+		}
+	}
+	ctx.RootDict["AF"] = append(af, ir)
+	return nil
+}
+
+// files[0] is the PDF, the rest are embedded into it; uploaded holds the
+// uploaded file names, in the same order.
+// opts["names"]        comma separated names for the attached files, in
+// order (default: the uploaded file name)
+// opts["description"]  optional, shown by viewers in the attachments panel
+// opts["relationship"] data | source | alternative | supplement | unspecified:
+// also register as associated file (e.g. data for a Factur-X invoice XML)
+func attachPDF(files, uploaded []string, opts map[string]string) (string, error) {
+	if len(files) < 2 {
+		return "", fmt.Errorf("attach: need a PDF and at least one file to attach")
+	}
+	input, extra := files[0], files[1:]
+
+	var names []string
+	if s := strings.TrimSpace(opts["names"]); s != "" {
+		for _, n := range strings.Split(s, ",") {
+			names = append(names, strings.TrimSpace(n))
+		}
+		if len(names) != len(extra) {
+			return "", fmt.Errorf("attach: %d names given for %d files", len(names), len(extra))
+		}
+	}
+
+	relationship := ""
+	if r := strings.ToLower(strings.TrimSpace(opts["relationship"])); r != "" {
+		var ok bool
+		if relationship, ok = afRelationships[r]; !ok {
+			return "", fmt.Errorf("attach: unknown relationship %q", opts["relationship"])
+		}
+	}
+
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return "", fmt.Errorf("attach: %w", err)
+	}
+	if err := ctx.LocateNameTree("EmbeddedFiles", false); err != nil {
+		return "", fmt.Errorf("attach: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, path := range extra {
+		name := uploaded[i+1]
+		if names != nil && names[i] != "" {
+			name = names[i]
+		}
+		name = safeFileName(name, fmt.Sprintf("attachment_%d", i+1))
+		if seen[name] {
+			return "", fmt.Errorf("attach: file name %q used twice", name)
+		}
+		seen[name] = true
+
+		if err := addAttachment(ctx, path, name, opts["description"]); err != nil {
+			return "", fmt.Errorf("attach: %s: %w", name, err)
+		}
+		if relationship != "" {
+			if err := associateFile(ctx, name, relationship); err != nil {
+				return "", fmt.Errorf("attach: %s: %w", name, err)
+			}
+		}
+	}
+
+	out := TempName("attached", ".pdf")
+	if err := api.WriteContextFile(ctx, out); err != nil {
+		DeleteFile(out)
+		log.Println("❌ Attach failed:", err)
+		return "", fmt.Errorf("attach: %w", err)
+	}
+
+	log.Println("✅ Files attached:", len(extra), "→", out)
+	return out, nil
+}
+
+// An attachment with the same name is replaced, so re-attaching an
+// updated invoice doesn't leave the old one behind.
+func addAttachment(ctx *model.Context, path, name, desc string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	mt := fi.ModTime()
+
+	if tree := ctx.Names["EmbeddedFiles"]; tree != nil {
+		if o, ok := tree.Value(name); ok {
+			if ir, ok := o.(types.IndirectRef); ok {
+				dropAssociatedFile(ctx, ir)
+			}
+			if _, err := ctx.RemoveAttachments([]string{name}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.AddAttachment(model.Attachment{Reader: f, ID: name, Desc: desc, ModTime: &mt}, false)
+}
+
+func dropAssociatedFile(ctx *model.Context, ir types.IndirectRef) {
+	af, err := ctx.DereferenceArray(ctx.RootDict["AF"])
+	if err != nil || af == nil {
+		return
+	}
+
+	var kept types.Array
+	for _, e := range af {
+		if r, ok := e.(types.IndirectRef); ok && r.ObjectNumber == ir.ObjectNumber {
+			continue
+		}
+		kept = append(kept, e)
+	}
+
+	if len(kept) == 0 {
+		delete(ctx.RootDict, "AF")
+		return
+	}
+	ctx.RootDict["AF"] = kept
+}
+
+// ----------------------------
+// DETACH
+// ----------------------------
+// Extracts every embedded file; each one becomes an output of the job.
+// A PDF without attachments is not an error: the report is just empty.
+func detachPDF(input string) ([]string, *detachReport, error) {
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("detach: %w", err)
+	}
+
+	report := &detachReport{Attachments: []attachmentInfo{}}
+
+	if err := ctx.LocateNameTree("EmbeddedFiles", false); err != nil {
+		return nil, nil, fmt.Errorf("detach: %w", err)
+	}
+	if ctx.Names["EmbeddedFiles"] == nil {
+		log.Println("✅ Detach: no embedded files")
+		return nil, report, nil
+	}
+
+	list, err := ctx.ExtractAttachments(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("detach: %w", err)
+	}
+
+	var outputs []string
+	used := map[string]bool{}
+
+	cleanup := func() {
+		for _, p := range outputs {
+			DeleteFile(p)
+		}
+	}
+
+	for i, a := range list {
+		name := a.FileName
+		if name == "" {
+			name = a.ID
+		}
+		base := safeFileName(name, fmt.Sprintf("attachment_%d", i+1))
+
+		// several attachments may share a file name
+		ext := filepath.Ext(base)
+		stem := strings.TrimSuffix(base, ext)
+		for n := 2; used[stem+ext]; n++ {
+			stem = fmt.Sprintf("%s_%d", strings.TrimSuffix(base, ext), n)
+		}
+		used[stem+ext] = true
+
+		out := TempName(stem, ext)
+		f, err := os.Create(out)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		size, err := io.Copy(f, a.Reader)
+		f.Close()
+		if err != nil {
+			DeleteFile(out)
+			cleanup()
+			return nil, nil, fmt.Errorf("detach: %s: %w", name, err)
+		}
+		outputs = append(outputs, out)
+
+		info := attachmentInfo{
+			Name:        name,
+			Description: a.Desc,
+			Size:        size,
+			File:        filepath.Base(out),
+		}
+		if a.ModTime != nil {
+			info.Modified = a.ModTime.UTC().Format(time.RFC3339)
+		}
+		if fs, err := attachmentFileSpec(ctx, a.ID); err == nil {
+			if r, ok := fs["AFRelationship"].(types.Name); ok {
+				info.Relationship = string(r)
+			}
+		}
+		report.Attachments = append(report.Attachments, info)
+	}
+
+	log.Println("✅ Files detached:", len(outputs))
+	return outputs, report, nil
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Ink coverage of one page, in percent of the checked area
+type pageCoverage struct {
+	Page     int     `json:"page"`
+	Coverage float64 `json:"coverage"`
+	Blank    bool    `json:"blank"`
+}
+
+type blankPagesReport struct {
+	Pages     int            `json:"pages"`
+	Threshold float64        `json:"threshold"`
+	Blank     []int          `json:"blank"`
+	Coverage  []pageCoverage `json:"coverage"`
+	Removed   bool           `json:"removed"`
+}
+
+// a pixel darker than this (0-255) counts as ink
+const inkLevel = 160
+
+// ----------------------------
+// INK COVERAGE
+// ----------------------------
+// Share of dark pixels inside the page, leaving out a border where
+// scanners leave shadows and punch holes. A dark pixel with no dark
+// neighbour is dust or sensor noise and is not counted.
+func inkCoverage(img image.Image, marginPct float64) float64 {
+	b := img.Bounds()
+	mx := int(float64(b.Dx()) * marginPct / 100)
+	my := int(float64(b.Dy()) * marginPct / 100)
+	area := image.Rect(b.Min.X+mx, b.Min.Y+my, b.Max.X-mx, b.Max.Y-my)
+	if area.Empty() {
+		return 0
+	}
+
+	w, h := area.Dx(), area.Dy()
+	dark := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			g := color.GrayModel.Convert(img.At(area.Min.X+x, area.Min.Y+y)).(color.Gray)
+			dark[y*w+x] = g.Y < inkLevel
+		}
+	}
+
+	isDark := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && dark[y*w+x]
+	}
+
+	ink := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !dark[y*w+x] {
+				continue
+			}
+			if isDark(x-1, y-1) || isDark(x, y-1) || isDark(x+1, y-1) ||
+				isDark(x-1, y) || isDark(x+1, y) ||
+				isDark(x-1, y+1) || isDark(x, y+1) || isDark(x+1, y+1) {
+				ink++
+			}
+		}
+	}
+
+	return float64(ink) * 100 / float64(w*h)
+}
+
+// One pdftoppm run for the whole file; output files are named
+// <prefix>-<page>.png with the page number zero padded.
+func renderGrayPages(input string, dpi int, dir string) (map[int]string, error) {
+	prefix := filepath.Join(dir, "page")
+
+	out, err := exec.Command("pdftoppm", "-gray", "-r", strconv.Itoa(dpi), "-png", input, prefix).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	files, _ := filepath.Glob(prefix + "-*.png")
+	pages := map[int]string{}
+	for _, f := range files {
+		n := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "page-"), ".png")
+		if p, err := strconv.Atoi(n); err == nil {
+			pages[p] = f
+		}
+	}
+	return pages, nil
+}
+
+func parseFloatOption(opts map[string]string, key string, def, min, max float64) (float64, error) {
+	v := strings.TrimSpace(strings.TrimSuffix(opts[key], "%"))
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < min || f > max {
+		return 0, fmt.Errorf("%s must be a number between %g and %g", key, min, max)
+	}
+	return f, nil
+}
+
+// ----------------------------
+// REMOVE BLANK PAGES
+// ----------------------------
+// opts["mode"]      remove (default) | report: only list the blank pages
+// opts["threshold"] max ink coverage of a blank page in percent, default 0.5
+// opts["margin"]    border ignored on each side in percent, default 5
+// opts["dpi"]       render resolution, default 50
+func removeBlankPages(input string, opts map[string]string) (string, *blankPagesReport, error) {
+	mode := opts["mode"]
+	if mode == "" {
+		mode = "remove"
+	}
+	if mode != "remove" && mode != "report" {
+		return "", nil, fmt.Errorf("remove-blank-pages: unknown mode %q", mode)
+	}
+
+	threshold, err := parseFloatOption(opts, "threshold", 0.5, 0, 100)
+	if err != nil {
+		return "", nil, fmt.Errorf("remove-blank-pages: %w", err)
+	}
+	margin, err := parseFloatOption(opts, "margin", 5, 0, 40)
+	if err != nil {
+		return "", nil, fmt.Errorf("remove-blank-pages: %w", err)
+	}
+	dpi := 50
+	if v := opts["dpi"]; v != "" {
+		if dpi, err = strconv.Atoi(v); err != nil || dpi < 20 || dpi > 150 {
+			return "", nil, fmt.Errorf("remove-blank-pages: dpi must be between 20 and 150")
+		}
+	}
+
+	total, err := pageCount(input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := os.MkdirTemp("/tmp", "blank_")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	renders, err := renderGrayPages(input, dpi, dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("remove-blank-pages: %w", err)
+	}
+
+	report := &blankPagesReport{Pages: total, Threshold: threshold, Blank: []int{}, Coverage: []pageCoverage{}}
+	blank := make([]bool, total+1)
+
+	for p := 1; p <= total; p++ {
+		path, ok := renders[p]
+		if !ok {
+			return "", nil, fmt.Errorf("remove-blank-pages: page %d was not rendered", p)
+		}
+		img, err := readPNG(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("remove-blank-pages: page %d: %w", p, err)
+		}
+
+		c := inkCoverage(img, margin)
+		blank[p] = c <= threshold
+		if blank[p] {
+			report.Blank = append(report.Blank, p)
+		}
+		report.Coverage = append(report.Coverage, pageCoverage{Page: p, Coverage: math.Round(c*1000) / 1000, Blank: blank[p]})
+	}
+
+	log.Println("✅ Blank pages detected:", len(report.Blank), "of", total)
+
+	if mode == "report" {
+		return "", report, nil
+	}
+
+	keep := keptRanges(blank, total)
+	if len(keep) == 0 {
+		return "", report, fmt.Errorf("remove-blank-pages: every page is blank")
+	}
+
+	out := TempName("no_blank", ".pdf")
+	if len(report.Blank) == 0 {
+		// nothing to drop: hand back the file untouched
+		if err := copyFile(input, out); err != nil {
+			return "", nil, err
+		}
+		return out, report, nil
+	}
+
+	if err := writePages(input, out, keep); err != nil {
+		return "", report, err
+	}
+	report.Removed = true
+
+	log.Println("✅ Blank pages removed:", report.Blank)
+	return out, report, nil
+}
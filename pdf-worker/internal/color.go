@@ -0,0 +1,481 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// ICC profiles shipped with Ghostscript (libgs-common)
+const gsICCDir = "/usr/share/color/icc/ghostscript"
+
+type colorReport struct {
+	Mode            string `json:"mode"`
+	Scope           string `json:"scope"`
+	Profile         string `json:"profile,omitempty"`
+	ImagesConverted int    `json:"imagesConverted,omitempty"`
+	ImagesSkipped   int    `json:"imagesSkipped,omitempty"`
+	InlineImages    int    `json:"inlineImagesUnchanged,omitempty"`
+	Note            string `json:"note,omitempty"`
+}
+
+// Reads the header of an uploaded ICC profile: "acsp" signature at 36,
+// data color space at 16
+func checkICCProfile(path, want string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head := make([]byte, 40)
+	if _, err := io.ReadFull(f, head); err != nil || string(head[36:40]) != "acsp" {
+		return fmt.Errorf("%s is not an ICC profile", filepath.Base(path))
+	}
+	if cs := strings.TrimSpace(string(head[16:20])); cs != want {
+		return fmt.Errorf("%s is a %s profile, need %s", filepath.Base(path), cs, want)
+	}
+	return nil
+}
+
+// ----------------------------
+// WHOLE DOCUMENT (Ghostscript)
+// ----------------------------
+// pdfwrite converts colors without rasterizing: text stays text and
+// vectors stay vectors. Images are not downsampled.
+func runGhostscriptColor(input, out, mode, profile string) error {
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dPDFSETTINGS=/prepress",
+		"-dAutoRotatePages=/None",
+		"-dDownsampleColorImages=false",
+		"-dDownsampleGrayImages=false",
+		"-dDownsampleMonoImages=false",
+		"-dNOPAUSE", "-dQUIET", "-dBATCH",
+	}
+
+	if mode == "gray" {
+		args = append(args, "-sColorConversionStrategy=Gray", "-dProcessColorModel=/DeviceGray")
+	} else {
+		args = append(args, "-sColorConversionStrategy=CMYK", "-dProcessColorModel=/DeviceCMYK",
+			"-sOutputICCProfile="+profile)
+	}
+
+	args = append(args, "-sOutputFile="+out, input)
+
+	outBytes, err := exec.Command("gs", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ghostscript color conversion failed: %v: %s", err, strings.TrimSpace(string(outBytes)))
+	}
+	return nil
+}
+
+// ----------------------------
+// IMAGES ONLY (ImageMagick)
+// ----------------------------
+// Ghostscript has no per-object-type conversion, so each image XObject
+// is decoded by pdfcpu, converted by ImageMagick (lcms for the ICC
+// step) and swapped in place. Everything else is left byte for byte.
+
+// Number of color components of an image color space, 0 if unknown
+func colorComponents(ctx *model.Context, o types.Object) int {
+	o, _ = ctx.Dereference(o)
+
+	switch cs := o.(type) {
+	case types.Name:
+		switch cs {
+		case "DeviceGray", "CalGray", "G":
+			return 1
+		case "DeviceRGB", "CalRGB", "RGB", "Lab":
+			return 3
+		case "DeviceCMYK", "CMYK":
+			return 4
+		}
+	case types.Array:
+		if len(cs) < 2 {
+			return 0
+		}
+		family, _ := cs[0].(types.Name)
+		switch family {
+		case "CalGray":
+			return 1
+		case "CalRGB", "Lab":
+			return 3
+		case "ICCBased":
+			if sd, _, err := ctx.DereferenceStreamDict(cs[1]); err == nil && sd != nil {
+				if n := sd.IntEntry("N"); n != nil {
+					return *n
+				}
+			}
+		}
+	}
+	return 0 // Indexed, Separation, DeviceN: always converted
+}
+
+// ImageMagick arguments that turn any decoded image into the target
+func imageMagickColorArgs(mode, profile string) []string {
+	if mode == "gray" {
+		return []string{"-alpha", "off", "-colorspace", "Gray"}
+	}
+	return []string{"-alpha", "off", "-colorspace", "sRGB", "-type", "TrueColor",
+		"-profile", filepath.Join(gsICCDir, "srgb.icc"), "-profile", profile}
+}
+
+func convertImageObject(ctx *model.Context, objNr int, sd *types.StreamDict, mode, profile, dir string) error {
+	wasJPEG := false
+	if f, ok := sd.Dict["Filter"].(types.Name); ok && f == "DCTDecode" {
+		wasJPEG = true
+	}
+
+	img, err := pdfcpu.ExtractImage(ctx, sd, false, "", objNr, false)
+	if err != nil {
+		return err
+	}
+	if img == nil {
+		return fmt.Errorf("unsupported image")
+	}
+
+	src := filepath.Join(dir, fmt.Sprintf("img_%d.%s", objNr, img.FileType))
+	data, err := io.ReadAll(img)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(src, data, 0644); err != nil {
+		return err
+	}
+
+	w, h := *sd.IntEntry("Width"), *sd.IntEntry("Height")
+
+	cs, channels, format := "DeviceGray", 1, "gray"
+	if mode == "cmyk" {
+		cs, channels, format = "DeviceCMYK", 4, "cmyk"
+	}
+
+	// gray JPEGs stay JPEG; CMYK JPEG inversion conventions differ
+	// between tools, so CMYK is always stored lossless
+	if wasJPEG && mode == "gray" {
+		format = "jpg"
+	}
+
+	args := append([]string{src}, imageMagickColorArgs(mode, profile)...)
+	args = append(args, "-depth", "8")
+	if format == "jpg" {
+		args = append(args, "-quality", "92")
+	}
+	args = append(args, format+":-")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("convert", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("convert failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var converted *types.StreamDict
+	if format == "jpg" {
+		converted, err = model.CreateDCTImageStreamDict(ctx.XRefTable, stdout.Bytes(), w, h, 8, cs)
+	} else {
+		if stdout.Len() != w*h*channels {
+			return fmt.Errorf("convert returned %d bytes for a %dx%d image", stdout.Len(), w, h)
+		}
+		converted, err = model.CreateFlateImageStreamDict(ctx.XRefTable, stdout.Bytes(), nil, w, h, 8, cs)
+	}
+	if err != nil {
+		return err
+	}
+
+	// transparency and visibility don't depend on the color space; a
+	// color key Mask (array) does, so only a stencil Mask is kept
+	for _, k := range []string{"SMask", "OC", "Intent", "Interpolate"} {
+		if v, ok := sd.Dict[k]; ok {
+			converted.Dict[k] = v
+		}
+	}
+	if m, ok := sd.Dict["Mask"].(types.IndirectRef); ok {
+		converted.Dict["Mask"] = m
+	}
+
+	ctx.Table[objNr].Object = *converted
+	return nil
+}
+
+// ----------------------------
+// INLINE IMAGES
+// ----------------------------
+// Inline images (BI … ID … EI) are part of a content stream, not objects
+// of their own, so the images scope can't swap them. They are counted
+// so the report can say what was left in the original colors.
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// Next occurrence of tok standing on its own as an operator, -1 if none
+func indexOperator(b []byte, from int, tok string) int {
+	for i := from; i+len(tok) <= len(b); i++ {
+		if string(b[i:i+len(tok)]) != tok {
+			continue
+		}
+		if i > 0 && !isPDFSpace(b[i-1]) {
+			continue
+		}
+		if j := i + len(tok); j < len(b) && !isPDFSpace(b[j]) && !isPDFDelim(b[j]) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// Counts BI operators, skipping strings and comments so text that reads
+// "BI" doesn't count, and skipping the image data itself.
+func countInlineImages(content []byte) int {
+	n, i := 0, 0
+
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case isPDFSpace(c):
+			i++
+
+		case c == '%':
+			for i < len(content) && content[i] != '\n' && content[i] != '\r' {
+				i++
+			}
+
+		case c == '(':
+			depth := 0
+			for ; i < len(content); i++ {
+				if content[i] == '\\' {
+					i++
+					continue
+				}
+				if content[i] == '(' {
+					depth++
+				} else if content[i] == ')' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			i++
+
+		case c == '<' && (i+1 >= len(content) || content[i+1] != '<'):
+			for i < len(content) && content[i] != '>' {
+				i++
+			}
+			i++
+
+		case isPDFDelim(c):
+			i++
+
+		default:
+			start := i
+			for i < len(content) && !isPDFSpace(content[i]) && !isPDFDelim(content[i]) {
+				i++
+			}
+			if string(content[start:i]) != "BI" {
+				continue
+			}
+			n++
+
+			// the image data follows ID and runs up to EI
+			id := indexOperator(content, i, "ID")
+			if id < 0 {
+				return n
+			}
+			ei := indexOperator(content, id+3, "EI")
+			if ei < 0 {
+				return n
+			}
+			i = ei + 2
+		}
+	}
+
+	return n
+}
+
+// Inline images in page contents and form XObjects (which also hold
+// annotation appearances)
+func inlineImageCount(ctx *model.Context) int {
+	n := 0
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		d, _, _, err := ctx.PageDict(p, false)
+		if err != nil || d == nil {
+			continue
+		}
+		if content, err := ctx.PageContent(d, p); err == nil {
+			n += countInlineImages(content)
+		}
+	}
+
+	for _, e := range ctx.Table {
+		if e == nil || e.Free {
+			continue
+		}
+		sd, ok := e.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		if st, ok := sd.Dict["Subtype"].(types.Name); !ok || st != "Form" {
+			continue
+		}
+		if sd.Decode() == nil {
+			n += countInlineImages(sd.Content)
+		}
+	}
+
+	return n
+}
+
+func convertImages(input, out, mode, profile string, report *colorReport) error {
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return err
+	}
+
+	if n := inlineImageCount(ctx); n > 0 {
+		log.Println("⚠️", n, "inline images left unchanged")
+		report.InlineImages = n
+		report.Note = "inline images are part of the page content and keep their colors with scope=images; use scope=all to convert them"
+	}
+
+	dir, err := os.MkdirTemp("/tmp", "color_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	want := 1
+	if mode == "cmyk" {
+		want = 4
+	}
+
+	// soft masks are images too, but hold alpha, not color
+	masks := map[int]bool{}
+	for _, e := range ctx.Table {
+		if e == nil || e.Free {
+			continue
+		}
+		if sd, ok := e.Object.(types.StreamDict); ok {
+			if ir, ok := sd.Dict["SMask"].(types.IndirectRef); ok {
+				masks[ir.ObjectNumber.Value()] = true
+			}
+		}
+	}
+
+	for objNr, e := range ctx.Table {
+		if e == nil || e.Free || masks[objNr] {
+			continue
+		}
+		sd, ok := e.Object.(types.StreamDict)
+		if !ok {
+			continue
+		}
+		if st, ok := sd.Dict["Subtype"].(types.Name); !ok || st != "Image" {
+			continue
+		}
+		if m, ok := sd.Dict["ImageMask"].(types.Boolean); ok && bool(m) {
+			continue // stencil: painted in the current fill color
+		}
+		if colorComponents(ctx, sd.Dict["ColorSpace"]) == want {
+			continue
+		}
+		if sd.IntEntry("Width") == nil || sd.IntEntry("Height") == nil {
+			report.ImagesSkipped++
+			continue
+		}
+
+		if err := convertImageObject(ctx, objNr, &sd, mode, profile, dir); err != nil {
+			log.Println("⚠️ Image", objNr, "left unchanged:", err)
+			report.ImagesSkipped++
+			continue
+		}
+		report.ImagesConverted++
+	}
+
+	if err := api.WriteContextFile(ctx, out); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ----------------------------
+// COLOR CONVERT TOOL
+// ----------------------------
+// files[0] is the PDF; for cmyk an .icc profile may follow as files[1]
+// (default: Ghostscript's default_cmyk.icc), for gray it is rejected.
+// names are the uploaded file names.
+// opts["mode"]  gray (default) | cmyk
+// opts["scope"] all (default) | images: leave text and vector colors alone
+func colorConvertPDF(files, names []string, opts map[string]string) (string, *colorReport, error) {
+	mode := opts["mode"]
+	if mode == "" {
+		mode = "gray"
+	}
+	if mode != "gray" && mode != "cmyk" {
+		return "", nil, fmt.Errorf("color-convert: unknown mode %q", mode)
+	}
+
+	scope := opts["scope"]
+	if scope == "" {
+		scope = "all"
+	}
+	if scope != "all" && scope != "images" {
+		return "", nil, fmt.Errorf("color-convert: unknown scope %q", scope)
+	}
+
+	report := &colorReport{Mode: mode, Scope: scope}
+
+	// gray needs no output profile; silently dropping an uploaded one
+	// would hide that it had no effect
+	if mode == "gray" && len(files) > 1 {
+		return "", nil, fmt.Errorf("color-convert: an ICC profile (%s) can only be used with mode=cmyk", names[1])
+	}
+
+	profile := ""
+	if mode == "cmyk" {
+		profile = filepath.Join(gsICCDir, "default_cmyk.icc")
+		report.Profile = "default_cmyk.icc"
+		if len(files) > 1 {
+			profile = files[1]
+			if err := checkICCProfile(profile, "CMYK"); err != nil {
+				return "", nil, fmt.Errorf("color-convert: %w", err)
+			}
+			report.Profile = names[1]
+		}
+	}
+
+	out := TempName("color_"+mode, ".pdf")
+
+	var err error
+	if scope == "images" {
+		err = convertImages(files[0], out, mode, profile, report)
+	} else {
+		err = runGhostscriptColor(files[0], out, mode, profile)
+	}
+	if err != nil {
+		DeleteFile(out)
+		log.Println("❌ Color conversion failed:", err)
+		return "", report, fmt.Errorf("color-convert: %w", err)
+	}
+
+	log.Println("✅ PDF converted to", mode+":", out)
+	return out, report, nil
+}
@@ -0,0 +1,504 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Visual difference of one page pair. OnlyIn is set when one file has
+// fewer pages.
+type visualPage struct {
+	Page          int     `json:"page"`
+	ChangedPixels int     `json:"changedPixels"`
+	ChangedRatio  float64 `json:"changedRatio"`
+	OnlyIn        string  `json:"onlyIn,omitempty"`
+}
+
+// One insertion or deletion; PageA is the page in the original (first
+// file), PageB in the revised one
+type textChange struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	PageA int    `json:"pageA,omitempty"`
+	PageB int    `json:"pageB,omitempty"`
+}
+
+type compareReport struct {
+	PagesA       int          `json:"pagesA"`
+	PagesB       int          `json:"pagesB"`
+	ChangedPages int          `json:"changedPages"`
+	Visual       []visualPage `json:"visual"`
+	Insertions   int          `json:"insertions"`
+	Deletions    int          `json:"deletions"`
+	Changes      []textChange `json:"changes"`
+}
+
+// ----------------------------
+// TEXT DIFF
+// ----------------------------
+// Lines are diffed first (cheap, keeps unchanged paragraphs aligned),
+// then the words of every changed block.
+type docToken struct {
+	Text string
+	Page int
+}
+
+type wordRun struct {
+	Kind diffKind
+	Text string
+}
+
+// what the HTML report shows for one changed block
+type diffHunk struct {
+	PageA, PageB  int
+	Before, After string
+	Runs          []wordRun
+}
+
+func documentLines(pages []layoutPage) []docToken {
+	var lines []docToken
+	for i, p := range pages {
+		for _, l := range p.Lines {
+			s, _ := l.text()
+			if s = strings.Join(strings.Fields(s), " "); s != "" {
+				lines = append(lines, docToken{s, i + 1})
+			}
+		}
+	}
+	return lines
+}
+
+func tokenTexts(tokens []docToken) []string {
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.Text
+	}
+	return texts
+}
+
+func splitWords(lines []docToken) []docToken {
+	var words []docToken
+	for _, l := range lines {
+		for _, w := range strings.Fields(l.Text) {
+			words = append(words, docToken{w, l.Page})
+		}
+	}
+	return words
+}
+
+func diffText(a, b []layoutPage, report *compareReport) []diffHunk {
+	la, lb := documentLines(a), documentLines(b)
+	ops := diffTokens(tokenTexts(la), tokenTexts(lb))
+
+	var hunks []diffHunk
+
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == diffEqual {
+			i++
+			continue
+		}
+
+		// one block of changed lines
+		var del, ins []docToken
+		start := i
+		for ; i < len(ops) && ops[i].Kind != diffEqual; i++ {
+			if ops[i].Kind == diffDelete {
+				del = append(del, la[ops[i].A])
+			} else {
+				ins = append(ins, lb[ops[i].B])
+			}
+		}
+
+		h := diffHunk{}
+		if start > 0 {
+			h.Before = lb[ops[start-1].B].Text
+		}
+		if i < len(ops) {
+			h.After = lb[ops[i].B].Text
+		}
+
+		wa, wb := splitWords(del), splitWords(ins)
+		wops := diffTokens(tokenTexts(wa), tokenTexts(wb))
+
+		for j := 0; j < len(wops); {
+			kind := wops[j].Kind
+			var words []string
+			pageA, pageB := 0, 0
+
+			for ; j < len(wops) && wops[j].Kind == kind; j++ {
+				op := wops[j]
+				if op.A >= 0 {
+					words = append(words, wa[op.A].Text)
+					if pageA == 0 {
+						pageA = wa[op.A].Page
+					}
+				} else {
+					words = append(words, wb[op.B].Text)
+				}
+				if op.B >= 0 && pageB == 0 {
+					pageB = wb[op.B].Page
+				}
+			}
+
+			text := strings.Join(words, " ")
+			h.Runs = append(h.Runs, wordRun{kind, text})
+
+			if h.PageA == 0 {
+				h.PageA = pageA
+			}
+			if h.PageB == 0 {
+				h.PageB = pageB
+			}
+
+			switch kind {
+			case diffDelete:
+				report.Deletions += len(words)
+				report.Changes = append(report.Changes, textChange{Type: "delete", Text: text, PageA: pageA})
+			case diffInsert:
+				report.Insertions += len(words)
+				report.Changes = append(report.Changes, textChange{Type: "insert", Text: text, PageB: pageB})
+			}
+		}
+
+		hunks = append(hunks, h)
+	}
+
+	return hunks
+}
+
+// ----------------------------
+// VISUAL DIFF
+// ----------------------------
+func renderPages(input string, pages int, dpi int, dir, prefix string) ([]string, error) {
+	var files []string
+
+	for p := 1; p <= pages; p++ {
+		base := filepath.Join(dir, fmt.Sprintf("%s_%03d", prefix, p))
+		n := strconv.Itoa(p)
+
+		out, err := exec.Command("pdftoppm", "-f", n, "-l", n, "-r", strconv.Itoa(dpi),
+			"-png", "-singlefile", input, base).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("pdftoppm page %d failed: %v: %s", p, err, string(out))
+		}
+		files = append(files, base+".png")
+	}
+
+	return files, nil
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+// ImageMagick's compare needs equal sizes: pad both renders with white
+// to the larger one. A missing page becomes a white page.
+func padToSameSize(a, b string, dir string, page int) (string, string, error) {
+	var imgA, imgB image.Image
+	var err error
+
+	if a != "" {
+		if imgA, err = readPNG(a); err != nil {
+			return "", "", err
+		}
+	}
+	if b != "" {
+		if imgB, err = readPNG(b); err != nil {
+			return "", "", err
+		}
+	}
+
+	w, h := 0, 0
+	for _, img := range []image.Image{imgA, imgB} {
+		if img != nil {
+			w = maxInt(w, img.Bounds().Dx())
+			h = maxInt(h, img.Bounds().Dy())
+		}
+	}
+
+	pad := func(img image.Image, path, name string) (string, error) {
+		if img != nil && img.Bounds().Dx() == w && img.Bounds().Dy() == h {
+			return path, nil
+		}
+		canvas := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		if img != nil {
+			draw.Draw(canvas, img.Bounds().Sub(img.Bounds().Min), img, img.Bounds().Min, draw.Src)
+		}
+		out := filepath.Join(dir, fmt.Sprintf("%s_%03d.png", name, page))
+		return out, writePNG(out, canvas)
+	}
+
+	if a, err = pad(imgA, a, "padA"); err != nil {
+		return "", "", err
+	}
+	if b, err = pad(imgB, b, "padB"); err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// compare exits 1 when the images differ; the AE metric (number of
+// differing pixels) is printed on stderr
+func imageDiff(a, b, out, fuzz string) (int, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("compare", "-metric", "AE", "-fuzz", fuzz,
+		"-highlight-color", "red", a, b, out)
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		err = nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("compare failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	fields := strings.Fields(stderr.String())
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("compare: no metric in output")
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("compare: unexpected metric %q", fields[0])
+	}
+	return int(v), nil
+}
+
+// Renders both files, diffs every page pair and collects the highlight
+// images into one PDF with the revised file's page sizes.
+func visualDiff(a, b string, pagesA, pagesB []layoutPage, dpi int, fuzz string, report *compareReport) (string, error) {
+	workDir, err := os.MkdirTemp("/tmp", "compare_")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(workDir)
+
+	rendersA, err := renderPages(a, len(pagesA), dpi, workDir, "a")
+	if err != nil {
+		return "", err
+	}
+	rendersB, err := renderPages(b, len(pagesB), dpi, workDir, "b")
+	if err != nil {
+		return "", err
+	}
+
+	total := maxInt(len(pagesA), len(pagesB))
+	var diffs []string
+	var sizes [][2]float64
+
+	for p := 1; p <= total; p++ {
+		vp := visualPage{Page: p}
+		ra, rb := "", ""
+		size := [2]float64{}
+
+		if p <= len(pagesA) {
+			ra = rendersA[p-1]
+			size = [2]float64{pagesA[p-1].Width, pagesA[p-1].Height}
+		} else {
+			vp.OnlyIn = "b"
+		}
+		if p <= len(pagesB) {
+			rb = rendersB[p-1]
+			size = [2]float64{pagesB[p-1].Width, pagesB[p-1].Height}
+		} else {
+			vp.OnlyIn = "a"
+		}
+
+		pa, pb, err := padToSameSize(ra, rb, workDir, p)
+		if err != nil {
+			return "", err
+		}
+
+		out := filepath.Join(workDir, fmt.Sprintf("diff_%03d.png", p))
+		changed, err := imageDiff(pa, pb, out, fuzz)
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", p, err)
+		}
+
+		if f, err := os.Open(out); err == nil {
+			cfg, err := png.DecodeConfig(f)
+			f.Close()
+			if err == nil && cfg.Width*cfg.Height > 0 {
+				vp.ChangedRatio = float64(changed) / float64(cfg.Width*cfg.Height)
+			}
+		}
+		vp.ChangedPixels = changed
+		if changed > 0 {
+			report.ChangedPages++
+		}
+
+		report.Visual = append(report.Visual, vp)
+		diffs = append(diffs, out)
+		sizes = append(sizes, size)
+	}
+
+	tmp := filepath.Join(workDir, "diff.pdf")
+	if err := api.ImportImagesFile(diffs, tmp, nil, nil); err != nil {
+		return "", err
+	}
+
+	// imported pages are one point per pixel; bring them back to paper size
+	moved := map[int][6]float64{}
+	return editPageGeometry(tmp, "compare", "", func(ctx *model.Context, p int) error {
+		return resizePage(ctx, p, sizes[p-1][0], sizes[p-1][1], "auto", "fit", moved)
+	})
+}
+
+// ----------------------------
+// HTML REPORT
+// ----------------------------
+func writeCompareHTML(out, nameA, nameB string, report *compareReport, hunks []diffHunk) error {
+	var b strings.Builder
+	esc := html.EscapeString
+
+	b.WriteString(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Comparison</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; color: #222; }
+.hunk { border-left: 3px solid #ccc; padding: .2em 1em; margin: 1em 0; }
+.loc { color: #777; font-size: .85em; }
+.ctx { color: #888; }
+del { background: #fdd; color: #900; }
+ins { background: #dfd; color: #060; text-decoration: none; }
+</style></head><body>
+`)
+
+	fmt.Fprintf(&b, "<h1>%s → %s</h1>\n", esc(nameA), esc(nameB))
+	fmt.Fprintf(&b, "<p>%d words inserted, %d words deleted, %d of %d pages visually changed.</p>\n",
+		report.Insertions, report.Deletions, report.ChangedPages, len(report.Visual))
+
+	if len(hunks) == 0 {
+		b.WriteString("<p>No text differences.</p>\n")
+	}
+
+	for _, h := range hunks {
+		b.WriteString(`<div class="hunk">`)
+		var loc []string
+		if h.PageA > 0 {
+			loc = append(loc, fmt.Sprintf("original page %d", h.PageA))
+		}
+		if h.PageB > 0 {
+			loc = append(loc, fmt.Sprintf("revised page %d", h.PageB))
+		}
+		fmt.Fprintf(&b, `<div class="loc">%s</div><p>`, strings.Join(loc, ", "))
+		if h.Before != "" {
+			fmt.Fprintf(&b, `<span class="ctx">%s</span><br>`, esc(h.Before))
+		}
+		for _, r := range h.Runs {
+			switch r.Kind {
+			case diffDelete:
+				fmt.Fprintf(&b, "<del>%s</del> ", esc(r.Text))
+			case diffInsert:
+				fmt.Fprintf(&b, "<ins>%s</ins> ", esc(r.Text))
+			default:
+				fmt.Fprintf(&b, "%s ", esc(r.Text))
+			}
+		}
+		if h.After != "" {
+			fmt.Fprintf(&b, `<br><span class="ctx">%s</span>`, esc(h.After))
+		}
+		b.WriteString("</p></div>\n")
+	}
+
+	b.WriteString("</body></html>\n")
+	return os.WriteFile(out, []byte(b.String()), 0644)
+}
+
+// ----------------------------
+// COMPARE TOOL
+// ----------------------------
+// files[0] is the original, files[1] the revised version; names are the
+// uploaded file names shown in the HTML report.
+// opts["dpi"]  render resolution for the visual diff, default 100
+// opts["fuzz"] color tolerance before a pixel counts as changed, default 5%
+//
+// Returns the highlight PDF and the HTML report; the JSON report is
+// stored with the job.
+func comparePDFs(files, names []string, opts map[string]string) ([]string, *compareReport, error) {
+	if len(files) != 2 {
+		return nil, nil, fmt.Errorf("compare: needs exactly 2 files, got %d", len(files))
+	}
+	// one upload picked twice would always report "no differences"
+	if same, err := sameContent(files[0], files[1]); err != nil {
+		return nil, nil, err
+	} else if same {
+		return nil, nil, fmt.Errorf("compare: both files are identical")
+	}
+
+	dpi := 100
+	if v := opts["dpi"]; v != "" {
+		var err error
+		if dpi, err = strconv.Atoi(v); err != nil || dpi < 36 || dpi > 300 {
+			return nil, nil, fmt.Errorf("compare: dpi must be between 36 and 300")
+		}
+	}
+	fuzz := opts["fuzz"]
+	if fuzz == "" {
+		fuzz = "5%"
+	}
+
+	pagesA, err := textLayout(files[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	pagesB, err := textLayout(files[1])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &compareReport{PagesA: len(pagesA), PagesB: len(pagesB), Changes: []textChange{}}
+	hunks := diffText(pagesA, pagesB, report)
+
+	diffPDF, err := visualDiff(files[0], files[1], pagesA, pagesB, dpi, fuzz, report)
+	if err != nil {
+		log.Println("❌ Visual diff failed:", err)
+		return nil, report, err
+	}
+
+	htmlOut := TempName("compare", ".html")
+	if err := writeCompareHTML(htmlOut, names[0], names[1], report, hunks); err != nil {
+		DeleteFile(diffPDF)
+		return nil, report, err
+	}
+
+	log.Println("✅ PDFs compared:", report.ChangedPages, "pages changed,",
+		report.Insertions, "words inserted,", report.Deletions, "deleted")
+	return []string{diffPDF, htmlOut}, report, nil
+}
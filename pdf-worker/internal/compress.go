@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// Ghostscript -dPDFSETTINGS presets, best quality first
+var compressProfiles = []string{"prepress", "printer", "ebook", "screen"}
+
+type compressSettings struct {
+	Profile   string
+	DPI       int
+	Grayscale bool
+}
+
+// Stored next to the job result so the frontend can show the savings.
+// When the original is kept no profile was applied: Profile is empty and
+// TriedProfile names the one that was tried (with targetSizeMB, the one
+// that got closest).
+type compressReport struct {
+	Profile        string  `json:"profile,omitempty"`
+	TriedProfile   string  `json:"triedProfile,omitempty"`
+	OriginalSize   int64   `json:"originalSize"`
+	CompressedSize int64   `json:"compressedSize"`
+	Ratio          float64 `json:"ratio"`
+	KeptOriginal   bool    `json:"keptOriginal"`
+	TargetSize     int64   `json:"targetSize,omitempty"`
+	TargetMet      bool    `json:"targetMet"`
+}
+
+func isCompressProfile(p string) bool {
+	for _, known := range compressProfiles {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------
+// GHOSTSCRIPT PASS
+// ----------------------------
+func runGhostscriptCompress(input, out string, s compressSettings) error {
+	args := []string{
+		"-sDEVICE=pdfwrite",
+		"-dCompatibilityLevel=1.4",
+		"-dPDFSETTINGS=/" + s.Profile,
+		"-dNOPAUSE", "-dQUIET", "-dBATCH",
+	}
+
+	if s.DPI > 0 {
+		dpi := strconv.Itoa(s.DPI)
+		args = append(args,
+			"-dDownsampleColorImages=true", "-dColorImageResolution="+dpi,
+			"-dDownsampleGrayImages=true", "-dGrayImageResolution="+dpi,
+			"-dDownsampleMonoImages=true", "-dMonoImageResolution="+dpi,
+		)
+	}
+
+	if s.Grayscale {
+		args = append(args,
+			"-sColorConversionStrategy=Gray",
+			"-dProcessColorModel=/DeviceGray",
+		)
+	}
+
+	args = append(args, "-sOutputFile="+out, input)
+
+	outBytes, err := exec.Command("gs", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ghostscript %s failed: %v: %s", s.Profile, err, string(outBytes))
+	}
+	return nil
+}
+
+// ----------------------------
+// TARGET SIZE SEARCH
+// ----------------------------
+// Tries the profiles from best to worst quality and keeps the first one
+// that fits under target. If none fits, the smallest result is kept.
+func compressToTarget(input string, target int64, s compressSettings) (string, string, error) {
+	best, bestProfile := "", ""
+	var bestSize int64
+
+	for _, p := range compressProfiles {
+		s.Profile = p
+		out := TempName("compressed_"+p, ".pdf")
+
+		if err := runGhostscriptCompress(input, out, s); err != nil {
+			DeleteFile(out) // Ghostscript can leave a partial file
+			log.Println("⚠️", err)
+			continue
+		}
+
+		size := fileSize(out)
+		log.Println("🗜 Profile", p, "→", size, "bytes")
+
+		if best == "" || size < bestSize {
+			if best != "" {
+				DeleteFile(best)
+			}
+			best, bestProfile, bestSize = out, p, size
+		} else {
+			DeleteFile(out)
+		}
+
+		if size <= target {
+			break
+		}
+	}
+
+	if best == "" {
+		return "", "", fmt.Errorf("no compression profile succeeded")
+	}
+
+	return best, bestProfile, nil
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Burns annotation and form field appearances into the page content and
+// removes the annotations. qpdf copies the appearance streams as vector
+// content, so text stays selectable; nothing is rasterized.
+// --generate-appearances fills in fields whose appearance the viewer was
+// supposed to build (NeedAppearances).
+func flattenAnnotations(input, out, scope string) error {
+	_, err := runQPDF("--generate-appearances", "--flatten-annotations="+scope, input, out)
+	return err
+}
+
+// ----------------------------
+// FLATTEN
+// ----------------------------
+// opts["scope"] all (default) | print (only annotations that would be
+// printed) | screen (only those shown on screen)
+func flattenPDF(input string, opts map[string]string) (string, error) {
+	scope := strings.ToLower(opts["scope"])
+	switch scope {
+	case "":
+		scope = "all"
+	case "all", "print", "screen":
+	default:
+		return "", fmt.Errorf("flatten: scope must be all, print or screen")
+	}
+
+	out := TempName("flattened", ".pdf")
+
+	if err := flattenAnnotations(input, out, scope); err != nil {
+		log.Println("❌ Flatten failed:", err)
+		return "", err
+	}
+
+	log.Println("✅ PDF flattened:", out)
+	return out, nil
+}
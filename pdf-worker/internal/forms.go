@@ -0,0 +1,407 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/form"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Where a field is drawn; a field can have several widgets (radio
+// buttons, or the same value repeated on several pages)
+type formWidget struct {
+	Page int        `json:"page"`
+	Rect [4]float64 `json:"rect"`
+}
+
+// One entry of the form schema returned by form-fields. Value is a
+// string, a bool (checkbox) or a list of strings (list box).
+type formField struct {
+	Name      string       `json:"name"`
+	ID        string       `json:"id"`
+	Type      string       `json:"type"`
+	Label     string       `json:"label,omitempty"`
+	Options   []string     `json:"options,omitempty"`
+	Value     interface{}  `json:"value"`
+	Default   interface{}  `json:"default,omitempty"`
+	Format    string       `json:"format,omitempty"`
+	MaxLen    int          `json:"maxLen,omitempty"`
+	Multiline bool         `json:"multiline,omitempty"`
+	Editable  bool         `json:"editable,omitempty"`
+	Multi     bool         `json:"multi,omitempty"`
+	Locked    bool         `json:"locked"`
+	Widgets   []formWidget `json:"widgets"`
+}
+
+type formSchema struct {
+	Fields []formField `json:"fields"`
+}
+
+func exportFormGroup(input string) (*form.FormGroup, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fg, err := api.ExportForm(f, input, nil)
+	if err != nil {
+		return nil, fmt.Errorf("form: %w", err)
+	}
+	if len(fg.Forms) == 0 {
+		return nil, fmt.Errorf("form: no form fields found")
+	}
+	return fg, nil
+}
+
+// ----------------------------
+// WIDGET RECTANGLES
+// ----------------------------
+// pdfcpu's export has no geometry, so walk the widget annotations of
+// every page and key them by fully qualified field name.
+func fieldName(ctx *model.Context, d types.Dict) string {
+	var parts []string
+
+	for i := 0; d != nil && i < 32; i++ {
+		if s, err := ctx.DereferenceStringOrHexLiteral(d["T"], model.V10, nil); err == nil && s != "" {
+			parts = append([]string{s}, parts...)
+		}
+		parent, err := ctx.DereferenceDict(d["Parent"])
+		if err != nil {
+			break
+		}
+		d = parent
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func formWidgets(input string) (map[string][]formWidget, error) {
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	widgets := map[string][]formWidget{}
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		d, _, _, err := ctx.PageDict(p, false)
+		if err != nil || d == nil {
+			continue
+		}
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			continue
+		}
+
+		for _, o := range annots {
+			ad, err := ctx.DereferenceDict(o)
+			if err != nil || ad == nil {
+				continue
+			}
+			if st, ok := ad["Subtype"].(types.Name); !ok || st != "Widget" {
+				continue
+			}
+			arr, err := ctx.DereferenceArray(ad["Rect"])
+			if err != nil || len(arr) != 4 {
+				continue
+			}
+			r, err := ctx.RectForArray(arr)
+			if err != nil {
+				continue
+			}
+
+			name := fieldName(ctx, ad)
+			widgets[name] = append(widgets[name], formWidget{
+				Page: p,
+				Rect: [4]float64{r.LL.X, r.LL.Y, r.UR.X, r.UR.Y},
+			})
+		}
+	}
+
+	return widgets, nil
+}
+
+// ----------------------------
+// FORM FIELDS (schema)
+// ----------------------------
+func formFieldsPDF(input string) (*formSchema, error) {
+	fg, err := exportFormGroup(input)
+	if err != nil {
+		return nil, err
+	}
+
+	widgets, err := formWidgets(input)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := &formSchema{Fields: []formField{}}
+	add := func(f formField) {
+		f.Widgets = widgets[f.Name]
+		if f.Widgets == nil {
+			f.Widgets = []formWidget{}
+		}
+		schema.Fields = append(schema.Fields, f)
+	}
+
+	fm := fg.Forms[0]
+	for _, f := range fm.TextFields {
+		add(formField{Name: f.Name, ID: f.ID, Type: "text", Label: f.AltName, Value: f.Value,
+			Default: emptyNil(f.Default), MaxLen: f.MaxLen, Multiline: f.Multiline, Locked: f.Locked})
+	}
+	for _, f := range fm.DateFields {
+		add(formField{Name: f.Name, ID: f.ID, Type: "date", Label: f.AltName, Value: f.Value,
+			Default: emptyNil(f.Default), Format: f.Format, Locked: f.Locked})
+	}
+	for _, f := range fm.CheckBoxes {
+		add(formField{Name: f.Name, ID: f.ID, Type: "checkbox", Label: f.AltName, Value: f.Value,
+			Default: f.Default, Locked: f.Locked})
+	}
+	for _, f := range fm.RadioButtonGroups {
+		add(formField{Name: f.Name, ID: f.ID, Type: "radio", Label: f.AltName, Options: f.Options,
+			Value: f.Value, Default: emptyNil(f.Default), Locked: f.Locked})
+	}
+	for _, f := range fm.ComboBoxes {
+		add(formField{Name: f.Name, ID: f.ID, Type: "combobox", Label: f.AltName, Options: f.Options,
+			Value: f.Value, Default: emptyNil(f.Default), Editable: f.Editable, Locked: f.Locked})
+	}
+	for _, f := range fm.ListBoxes {
+		values := f.Values
+		if values == nil {
+			values = []string{}
+		}
+		var def interface{}
+		if len(f.Defaults) > 0 {
+			def = f.Defaults
+		}
+		add(formField{Name: f.Name, ID: f.ID, Type: "listbox", Label: f.AltName, Options: f.Options,
+			Value: values, Default: def, Multi: f.Multi, Locked: f.Locked})
+	}
+
+	// document order: by page, then top to bottom, left to right
+	sort.SliceStable(schema.Fields, func(i, j int) bool {
+		a, b := schema.Fields[i].Widgets, schema.Fields[j].Widgets
+		if len(a) == 0 || len(b) == 0 {
+			return len(a) > len(b)
+		}
+		if a[0].Page != b[0].Page {
+			return a[0].Page < b[0].Page
+		}
+		if a[0].Rect[3] != b[0].Rect[3] {
+			return a[0].Rect[3] > b[0].Rect[3]
+		}
+		return a[0].Rect[0] < b[0].Rect[0]
+	})
+
+	log.Println("✅ Form fields read:", len(schema.Fields))
+	return schema, nil
+}
+
+func emptyNil(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// ----------------------------
+// FORM FILL
+// ----------------------------
+// JSON values may be strings, booleans (checkboxes) or string arrays
+// (list boxes); a checkbox also takes "yes"/"no".
+func fillValue(name string, v interface{}) (string, *bool, []string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil, nil, nil
+	case bool:
+		return fmt.Sprintf("%t", t), &t, nil, nil
+	case float64:
+		return fmt.Sprintf("%v", t), nil, nil, nil
+	case []interface{}:
+		var list []string
+		for _, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return "", nil, nil, fmt.Errorf("form-fill: field %q: list values must be strings", name)
+			}
+			list = append(list, s)
+		}
+		return strings.Join(list, ","), nil, list, nil
+	case nil:
+		return "", nil, nil, nil
+	}
+	return "", nil, nil, fmt.Errorf("form-fill: field %q: unsupported value %v", name, v)
+}
+
+// Copies the requested values into pdfcpu's exported form so fields
+// keep their IDs, types and locks. Unknown names are an error: a typo
+// would otherwise silently leave a field empty.
+func applyFormValues(fm *form.Form, values map[string]interface{}) error {
+	used := map[string]bool{}
+
+	lookup := func(id, name string) (interface{}, bool) {
+		for _, k := range []string{name, id} {
+			if v, ok := values[k]; ok && k != "" {
+				used[k] = true
+				return v, true
+			}
+		}
+		return nil, false
+	}
+
+	for _, f := range fm.TextFields {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, _, _, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			f.Value = s
+		}
+	}
+	for _, f := range fm.DateFields {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, _, _, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			f.Value = s
+		}
+	}
+	for _, f := range fm.CheckBoxes {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, b, _, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			if b == nil {
+				on, err := parseYesNo(s)
+				if err != nil {
+					return fmt.Errorf("form-fill: field %q: %w", f.Name, err)
+				}
+				b = &on
+			}
+			f.Value = *b
+		}
+	}
+	for _, f := range fm.RadioButtonGroups {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, _, _, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			f.Value = s
+		}
+	}
+	for _, f := range fm.ComboBoxes {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, _, _, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			f.Value = s
+		}
+	}
+	for _, f := range fm.ListBoxes {
+		if v, ok := lookup(f.ID, f.Name); ok {
+			s, _, list, err := fillValue(f.Name, v)
+			if err != nil {
+				return err
+			}
+			if list == nil && s != "" {
+				list = []string{s}
+			}
+			f.Values = list
+		}
+	}
+
+	var unknown []string
+	for k := range values {
+		if !used[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("form-fill: unknown fields: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// opts["fields"]  JSON object: {"name": "Jane", "agree": true, "langs": ["en", "fr"]}
+// opts["flatten"] yes: bake the values into the page, no longer editable
+func formFillPDF(input string, opts map[string]string) (string, error) {
+	raw := strings.TrimSpace(opts["fields"])
+	if raw == "" {
+		return "", fmt.Errorf("form-fill: missing fields option")
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return "", fmt.Errorf("form-fill: fields must be a JSON object: %w", err)
+	}
+
+	flatten := false
+	if opts["flatten"] != "" {
+		var err error
+		if flatten, err = parseYesNo(opts["flatten"]); err != nil {
+			return "", fmt.Errorf("form-fill: flatten: %w", err)
+		}
+	}
+
+	fg, err := exportFormGroup(input)
+	if err != nil {
+		return "", err
+	}
+	if err := applyFormValues(&fg.Forms[0], values); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(fg)
+	if err != nil {
+		return "", err
+	}
+
+	in, err := os.Open(input)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out := TempName("filled", ".pdf")
+	w, err := os.Create(out)
+	if err != nil {
+		return "", err
+	}
+
+	err = api.FillForm(in, bytes.NewReader(data), w, nil)
+	w.Close()
+	if err != nil {
+		DeleteFile(out)
+		log.Println("❌ Form fill failed:", err)
+		return "", fmt.Errorf("form-fill: %w", err)
+	}
+
+	if flatten {
+		flat := TempName("filled_flat", ".pdf")
+		err := flattenAnnotations(out, flat, "all")
+		DeleteFile(out)
+		if err != nil {
+			log.Println("❌ Flatten failed:", err)
+			return "", err
+		}
+		out = flat
+	}
+
+	log.Println("✅ Form filled:", len(values), "fields →", out)
+	return out, nil
+}
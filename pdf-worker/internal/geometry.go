@@ -0,0 +1,672 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// ----------------------------
+// SHARED HELPERS
+// ----------------------------
+// Geometry options are given as the reader sees the page (after /Rotate);
+// everything below converts between that view and the page's own
+// coordinates.
+
+// Pages the tool applies to; an empty opts["pages"] means all of them
+func selectedPages(expr string, total int) (map[int]bool, error) {
+	sel := map[int]bool{}
+
+	ranges := []pageRange{{1, total}}
+	if strings.TrimSpace(expr) != "" {
+		var err error
+		if ranges, err = parsePageRanges(expr, total); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, r := range ranges {
+		for _, p := range r.Pages() {
+			sel[p] = true
+		}
+	}
+	return sel, nil
+}
+
+// "10" all sides, "10,20" top/bottom and left/right, "10,20,30,40" top,
+// right, bottom, left (like CSS). Values are in points.
+type margins struct {
+	Top, Right, Bottom, Left float64
+}
+
+func parseMargins(s string) (margins, error) {
+	var v []float64
+	for _, part := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || f < 0 {
+			return margins{}, fmt.Errorf("invalid margin %q (points, e.g. 36 or 36,18,36,18)", s)
+		}
+		v = append(v, f)
+	}
+
+	switch len(v) {
+	case 1:
+		return margins{v[0], v[0], v[0], v[0]}, nil
+	case 2:
+		return margins{v[0], v[1], v[0], v[1]}, nil
+	case 4:
+		return margins{v[0], v[1], v[2], v[3]}, nil
+	}
+	return margins{}, fmt.Errorf("invalid margin %q: give 1, 2 or 4 values", s)
+}
+
+// visible part of a page (CropBox, else MediaBox) and its rotation
+// normalised to 0, 90, 180 or 270
+func visibleBox(ctx *model.Context, pageNr int) (types.Dict, *types.Rectangle, int, error) {
+	d, _, inh, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if d == nil || inh == nil || inh.MediaBox == nil {
+		return nil, nil, 0, fmt.Errorf("page %d: missing MediaBox", pageNr)
+	}
+
+	box := inh.MediaBox
+	if inh.CropBox != nil {
+		box = inh.CropBox
+	}
+
+	return d, box, ((inh.Rotate % 360) + 360) % 360, nil
+}
+
+// size of the box as displayed
+func displaySize(box *types.Rectangle, rot int) (float64, float64) {
+	if rot == 90 || rot == 270 {
+		return box.Height(), box.Width()
+	}
+	return box.Width(), box.Height()
+}
+
+// Maps a point in display space (origin at the visible box's lower left
+// corner, after rotation) back to page space. PDF rotates clockwise.
+func displayToPage(box *types.Rectangle, rot int, x, y float64) (float64, float64) {
+	w, h := box.Width(), box.Height()
+
+	var u, v float64
+	switch rot {
+	case 90:
+		u, v = w-y, x
+	case 180:
+		u, v = w-x, h-y
+	case 270:
+		u, v = y, h-x
+	default:
+		u, v = x, y
+	}
+
+	return box.LL.X + u, box.LL.Y + v
+}
+
+func displayRectToPage(box *types.Rectangle, rot int, llx, lly, urx, ury float64) *types.Rectangle {
+	x1, y1 := displayToPage(box, rot, llx, lly)
+	x2, y2 := displayToPage(box, rot, urx, ury)
+	return types.NewRectangle(math.Min(x1, x2), math.Min(y1, y2), math.Max(x1, x2), math.Max(y1, y2))
+}
+
+// ----------------------------
+// RESIZE
+// ----------------------------
+// Target size from opts["paper"] (a paper name or "WxH" in points) and
+// opts["orientation"]; auto follows each page's own orientation.
+func targetSize(opts map[string]string) (float64, float64, error) {
+	paper := opts["paper"]
+	if paper == "" {
+		return 0, 0, fmt.Errorf("resize: missing paper option")
+	}
+
+	var w, h float64
+	if name, ok := paperName(paper); ok {
+		w, h = paperSizes[name][0], paperSizes[name][1]
+	} else if dim, ok := types.PaperSize[paper]; ok {
+		w, h = dim.Width, dim.Height
+	} else if _, err := fmt.Sscanf(strings.ToLower(paper), "%fx%f", &w, &h); err != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("resize: unknown paper %q (use a name like A4 or WxH in points)", paper)
+	}
+
+	return w, h, nil
+}
+
+// cm operator taking page space to the new page: display rotation,
+// then scale and offset
+func resizeMatrix(box *types.Rectangle, rot int, sx, sy, tx, ty float64) [6]float64 {
+	w, h := box.Width(), box.Height()
+	x0, y0 := box.LL.X, box.LL.Y
+
+	switch rot {
+	case 90:
+		return [6]float64{0, -sy, sx, 0, -sx*y0 + tx, sy*(w+x0) + ty}
+	case 180:
+		return [6]float64{-sx, 0, 0, -sy, sx*(w+x0) + tx, sy*(h+y0) + ty}
+	case 270:
+		return [6]float64{0, sy, -sx, 0, sx*(h+y0) + tx, -sy*x0 + ty}
+	}
+	return [6]float64{sx, 0, 0, sy, -sx*x0 + tx, -sy*y0 + ty}
+}
+
+func resizePage(ctx *model.Context, pageNr int, tw, th float64, orientation, scaling string, moved map[int][6]float64) error {
+	d, box, rot, err := visibleBox(ctx, pageNr)
+	if err != nil {
+		return err
+	}
+
+	dw, dh := displaySize(box, rot)
+
+	// keep portrait pages portrait unless asked otherwise
+	portrait := dh >= dw
+	switch orientation {
+	case "portrait":
+		portrait = true
+	case "landscape":
+		portrait = false
+	}
+	W, H := math.Min(tw, th), math.Max(tw, th)
+	if !portrait {
+		W, H = H, W
+	}
+
+	sx, sy := W/dw, H/dh
+	switch scaling {
+	case "fit":
+		sx = math.Min(sx, sy)
+		sy = sx
+	case "fill":
+		sx = math.Max(sx, sy)
+		sy = sx
+	}
+
+	tx, ty := (W-sx*dw)/2, (H-sy*dh)/2
+	m := resizeMatrix(box, rot, sx, sy, tx, ty)
+
+	bb, err := ctx.PageContent(d, pageNr)
+	if err != nil && err != model.ErrNoContent {
+		return err
+	}
+
+	// clip to the old visible area so content hidden by a CropBox stays
+	// hidden, then place the page
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "q %.4f %.4f %.4f %.4f re W n %.5f %.5f %.5f %.5f %.5f %.5f cm\n",
+		math.Max(tx, 0), math.Max(ty, 0), math.Min(sx*dw, W), math.Min(sy*dh, H),
+		m[0], m[1], m[2], m[3], m[4], m[5])
+	buf.Write(bb)
+	buf.WriteString("\nQ")
+
+	sd, err := ctx.NewStreamDictForBuf(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	ir, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+	d["Contents"] = *ir
+
+	// links and other annotations (rects, markup points, appearances)
+	// move with the content
+	transformAnnotations(ctx, d, m, moved)
+
+	// set rather than delete: CropBox and Rotate may be inherited
+	d.Update("MediaBox", types.RectForDim(W, H).Array())
+	d.Update("CropBox", types.RectForDim(W, H).Array())
+	d.Update("Rotate", types.Integer(0))
+	for _, k := range []string{"BleedBox", "TrimBox", "ArtBox"} {
+		d.Delete(k)
+	}
+
+	return nil
+}
+
+// Applies a, then b (PDF matrices act on row vectors)
+func mulMatrix(a, b [6]float64) [6]float64 {
+	return [6]float64{
+		a[0]*b[0] + a[1]*b[2], a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2], a[2]*b[1] + a[3]*b[3],
+		a[4]*b[0] + a[5]*b[2] + b[4], a[4]*b[1] + a[5]*b[3] + b[5],
+	}
+}
+
+func transformPoint(m [6]float64, x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// Bounding box of a transformed rectangle
+func transformRect(m [6]float64, r *types.Rectangle) *types.Rectangle {
+	x1, y1 := transformPoint(m, r.LL.X, r.LL.Y)
+	x2, y2 := transformPoint(m, r.UR.X, r.LL.Y)
+	x3, y3 := transformPoint(m, r.UR.X, r.UR.Y)
+	x4, y4 := transformPoint(m, r.LL.X, r.UR.Y)
+
+	return types.NewRectangle(
+		math.Min(math.Min(x1, x2), math.Min(x3, x4)), math.Min(math.Min(y1, y2), math.Min(y3, y4)),
+		math.Max(math.Max(x1, x2), math.Max(x3, x4)), math.Max(math.Max(y1, y2), math.Max(y3, y4)))
+}
+
+// Flat x y x y ... arrays (QuadPoints, Vertices, L, CL)
+func transformPoints(ctx *model.Context, o types.Object, m [6]float64) (types.Array, bool) {
+	arr, err := ctx.DereferenceArray(o)
+	if err != nil || arr == nil || len(arr)%2 != 0 {
+		return nil, false
+	}
+
+	pts := make([]float64, len(arr))
+	for i, v := range arr {
+		if pts[i], err = ctx.DereferenceNumber(v); err != nil {
+			return nil, false
+		}
+	}
+	for i := 0; i < len(pts); i += 2 {
+		pts[i], pts[i+1] = transformPoint(m, pts[i], pts[i+1])
+	}
+	return types.NewNumberArray(pts...), true
+}
+
+// A viewer draws an appearance stream by fitting its BBox (after the
+// stream's /Matrix) into the annotation's Rect. That fit and m are
+// folded into /Matrix, so once Rect is transformed too the fit is the
+// identity and the appearance turns and scales exactly like the page
+// content. moved keeps the original /Matrix of streams already handled:
+// a stream shared by several annotations gets a transformed copy for
+// every further use.
+func transformAppearance(ctx *model.Context, ir types.IndirectRef, rect *types.Rectangle, m [6]float64, moved map[int][6]float64) types.Object {
+	sd, _, err := ctx.DereferenceStreamDict(ir)
+	if err != nil || sd == nil {
+		return ir
+	}
+	arr, err := ctx.DereferenceArray(sd.Dict["BBox"])
+	if err != nil || len(arr) != 4 {
+		return ir
+	}
+	bbox, err := ctx.RectForArray(arr)
+	if err != nil {
+		return ir
+	}
+
+	objNr := ir.ObjectNumber.Value()
+	old, shared := moved[objNr]
+	if !shared {
+		old = [6]float64{1, 0, 0, 1, 0, 0}
+		if arr, err := ctx.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(arr) == 6 {
+			for i, v := range arr {
+				old[i], _ = ctx.DereferenceNumber(v)
+			}
+		}
+	}
+
+	box := transformRect(old, bbox)
+	if box.Width() == 0 || box.Height() == 0 {
+		return ir
+	}
+	ax, ay := rect.Width()/box.Width(), rect.Height()/box.Height()
+	fit := [6]float64{ax, 0, 0, ay, rect.LL.X - ax*box.LL.X, rect.LL.Y - ay*box.LL.Y}
+	nm := mulMatrix(mulMatrix(old, fit), m)
+
+	if !shared {
+		moved[objNr] = old
+		sd.Dict["Matrix"] = types.NewNumberArray(nm[:]...)
+		return ir
+	}
+
+	clone := sd.Clone().(types.StreamDict)
+	clone.Dict["Matrix"] = types.NewNumberArray(nm[:]...)
+	copyIR, err := ctx.IndRefForNewObject(clone)
+	if err != nil {
+		return ir
+	}
+	return *copyIR
+}
+
+func transformAnnotations(ctx *model.Context, d types.Dict, m [6]float64, moved map[int][6]float64) {
+	annots, err := ctx.DereferenceArray(d["Annots"])
+	if err != nil {
+		return
+	}
+
+	for _, o := range annots {
+		ad, err := ctx.DereferenceDict(o)
+		if err != nil || ad == nil {
+			continue
+		}
+		arr, err := ctx.DereferenceArray(ad["Rect"])
+		if err != nil || len(arr) != 4 {
+			continue
+		}
+		r, err := ctx.RectForArray(arr)
+		if err != nil {
+			continue
+		}
+
+		// normal, rollover and down appearances; each is a stream or a
+		// dict of streams by state (checkbox on/off)
+		if ap, err := ctx.DereferenceDict(ad["AP"]); err == nil && ap != nil {
+			for _, k := range []string{"N", "R", "D"} {
+				switch v := ap[k].(type) {
+				case types.IndirectRef:
+					if sd, _, err := ctx.DereferenceStreamDict(v); err == nil && sd != nil {
+						ap[k] = transformAppearance(ctx, v, r, m, moved)
+						continue
+					}
+					states, err := ctx.DereferenceDict(v)
+					if err != nil || states == nil {
+						continue
+					}
+					for state, sv := range states {
+						if sir, ok := sv.(types.IndirectRef); ok {
+							states[state] = transformAppearance(ctx, sir, r, m, moved)
+						}
+					}
+				case types.Dict:
+					for state, sv := range v {
+						if sir, ok := sv.(types.IndirectRef); ok {
+							v[state] = transformAppearance(ctx, sir, r, m, moved)
+						}
+					}
+				}
+			}
+		}
+
+		ad["Rect"] = transformRect(m, r).Array()
+
+		// markup geometry is in page space as well
+		for _, k := range []string{"QuadPoints", "Vertices", "L", "CL"} {
+			if pts, ok := transformPoints(ctx, ad[k], m); ok {
+				ad[k] = pts
+			}
+		}
+		if ink, err := ctx.DereferenceArray(ad["InkList"]); err == nil && ink != nil {
+			for i, path := range ink {
+				if pts, ok := transformPoints(ctx, path, m); ok {
+					ink[i] = pts
+				}
+			}
+		}
+	}
+}
+
+// opts["paper"]       A4, Letter, any pdfcpu paper name, or WxH in points
+// opts["orientation"] auto (default) | portrait | landscape
+// opts["scaling"]     fit (default, letterbox) | fill (crop overflow) | stretch
+// opts["pages"]       page range, default all
+func resizePDF(input string, opts map[string]string) (string, error) {
+	tw, th, err := targetSize(opts)
+	if err != nil {
+		return "", err
+	}
+
+	orientation := strings.ToLower(opts["orientation"])
+	switch orientation {
+	case "", "auto", "portrait", "landscape":
+	default:
+		return "", fmt.Errorf("resize: orientation must be auto, portrait or landscape")
+	}
+
+	scaling := strings.ToLower(opts["scaling"])
+	switch scaling {
+	case "":
+		scaling = "fit"
+	case "fit", "fill", "stretch":
+	default:
+		return "", fmt.Errorf("resize: scaling must be fit, fill or stretch")
+	}
+
+	moved := map[int][6]float64{}
+	return editPageGeometry(input, "resized", opts["pages"], func(ctx *model.Context, p int) error {
+		return resizePage(ctx, p, tw, th, orientation, scaling, moved)
+	})
+}
+
+// ----------------------------
+// CROP
+// ----------------------------
+// Ghostscript's bbox device prints the inked area of every page as
+// "%%HiResBoundingBox: llx lly urx ury", relative to the CropBox and
+// after rotation.
+var hiResBBox = regexp.MustCompile(`%%HiResBoundingBox:\s*([\d.]+)\s+([\d.]+)\s+([\d.]+)\s+([\d.]+)`)
+
+func inkBoxes(input string) ([][4]float64, error) {
+	out, err := exec.Command("gs",
+		"-q", "-dSAFER", "-dNOPAUSE", "-dBATCH",
+		"-dUseCropBox",
+		"-sDEVICE=bbox",
+		input,
+	).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ghostscript bbox failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	var boxes [][4]float64
+	for _, m := range hiResBBox.FindAllStringSubmatch(string(out), -1) {
+		var b [4]float64
+		for i := range b {
+			b[i], _ = strconv.ParseFloat(m[i+1], 64)
+		}
+		boxes = append(boxes, b)
+	}
+	return boxes, nil
+}
+
+// opts["margins"] amount to cut from each side, or
+// opts["auto"]    true: cut to the inked area plus opts["padding"] (points)
+// opts["pages"]   page range, default all
+func cropPDF(input string, opts map[string]string) (string, error) {
+	auto := false
+	if opts["auto"] != "" {
+		var err error
+		if auto, err = parseYesNo(opts["auto"]); err != nil {
+			return "", fmt.Errorf("crop: auto: %w", err)
+		}
+	}
+
+	if auto {
+		padding := 0.0
+		if p := opts["padding"]; p != "" {
+			v, err := strconv.ParseFloat(p, 64)
+			if err != nil || v < 0 {
+				return "", fmt.Errorf("crop: invalid padding %q", p)
+			}
+			padding = v
+		}
+
+		boxes, err := inkBoxes(input)
+		if err != nil {
+			return "", err
+		}
+
+		return editPageGeometry(input, "cropped", opts["pages"], func(ctx *model.Context, p int) error {
+			if p > len(boxes) {
+				return fmt.Errorf("crop: no bounding box for page %d", p)
+			}
+			b := boxes[p-1]
+			if b[2] <= b[0] || b[3] <= b[1] {
+				log.Println("⚠️ crop: page", p, "is blank, left as is")
+				return nil
+			}
+
+			d, box, rot, err := visibleBox(ctx, p)
+			if err != nil {
+				return err
+			}
+			dw, dh := displaySize(box, rot)
+
+			r := displayRectToPage(box, rot,
+				math.Max(b[0]-padding, 0), math.Max(b[1]-padding, 0),
+				math.Min(b[2]+padding, dw), math.Min(b[3]+padding, dh))
+			d.Update("CropBox", r.Array())
+			return nil
+		})
+	}
+
+	if opts["margins"] == "" {
+		return "", fmt.Errorf("crop: give margins or auto=true")
+	}
+	m, err := parseMargins(opts["margins"])
+	if err != nil {
+		return "", fmt.Errorf("crop: %w", err)
+	}
+
+	return editPageGeometry(input, "cropped", opts["pages"], func(ctx *model.Context, p int) error {
+		d, box, rot, err := visibleBox(ctx, p)
+		if err != nil {
+			return err
+		}
+		dw, dh := displaySize(box, rot)
+
+		if m.Left+m.Right >= dw || m.Top+m.Bottom >= dh {
+			return fmt.Errorf("crop: margins are larger than page %d (%.0fx%.0f pt)", p, dw, dh)
+		}
+
+		r := displayRectToPage(box, rot, m.Left, m.Bottom, dw-m.Right, dh-m.Top)
+		d.Update("CropBox", r.Array())
+		return nil
+	})
+}
+
+// ----------------------------
+// ADD MARGIN
+// ----------------------------
+// opts["margins"] space added on each side (points)
+// opts["gutter"]  extra space on the binding edge: left of odd pages and
+// right of even ones (opts["binding"]=right swaps them)
+// opts["pages"]   page range, default all
+func addMarginPDF(input string, opts map[string]string) (string, error) {
+	var m margins
+	if opts["margins"] != "" {
+		var err error
+		if m, err = parseMargins(opts["margins"]); err != nil {
+			return "", fmt.Errorf("add-margin: %w", err)
+		}
+	}
+
+	gutter := 0.0
+	if g := opts["gutter"]; g != "" {
+		v, err := strconv.ParseFloat(g, 64)
+		if err != nil || v < 0 {
+			return "", fmt.Errorf("add-margin: invalid gutter %q", g)
+		}
+		gutter = v
+	}
+
+	binding := strings.ToLower(opts["binding"])
+	if binding != "" && binding != "left" && binding != "right" {
+		return "", fmt.Errorf("add-margin: binding must be left or right")
+	}
+
+	if m == (margins{}) && gutter == 0 {
+		return "", fmt.Errorf("add-margin: give margins and/or gutter")
+	}
+
+	return editPageGeometry(input, "margin", opts["pages"], func(ctx *model.Context, p int) error {
+		d, box, rot, err := visibleBox(ctx, p)
+		if err != nil {
+			return err
+		}
+		dw, dh := displaySize(box, rot)
+
+		pm := m
+		if (p%2 == 1) == (binding != "right") {
+			pm.Left += gutter
+		} else {
+			pm.Right += gutter
+		}
+
+		r := displayRectToPage(box, rot, -pm.Left, -pm.Bottom, dw+pm.Right, dh+pm.Top)
+
+		// the MediaBox may extend past the CropBox: clip to the old visible
+		// area so content that was cropped out doesn't show up in the margin
+		if err := clipContent(ctx, d, p, box); err != nil {
+			return err
+		}
+
+		// the new area lies outside the old boxes, so all of them grow
+		d.Update("MediaBox", r.Array())
+		d.Update("CropBox", r.Array())
+		for _, k := range []string{"BleedBox", "TrimBox", "ArtBox"} {
+			d.Delete(k)
+		}
+		return nil
+	})
+}
+
+// Wraps the page content in a clip to box (page space)
+func clipContent(ctx *model.Context, d types.Dict, pageNr int, box *types.Rectangle) error {
+	bb, err := ctx.PageContent(d, pageNr)
+	if err == model.ErrNoContent {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "q %.4f %.4f %.4f %.4f re W n\n", box.LL.X, box.LL.Y, box.Width(), box.Height())
+	buf.Write(bb)
+	buf.WriteString("\nQ")
+
+	sd, err := ctx.NewStreamDictForBuf(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	ir, err := ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+	d["Contents"] = *ir
+	return nil
+}
+
+// ----------------------------
+// PAGE LOOP
+// ----------------------------
+func editPageGeometry(input, name, pages string, edit func(*model.Context, int) error) (string, error) {
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return "", err
+	}
+
+	sel, err := selectedPages(pages, ctx.PageCount)
+	if err != nil {
+		return "", err
+	}
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		if !sel[p] {
+			continue
+		}
+		if err := edit(ctx, p); err != nil {
+			return "", err
+		}
+	}
+
+	out := TempName(name, ".pdf")
+	if err := api.WriteContextFile(ctx, out); err != nil {
+		log.Println("❌ Writing", name, "PDF failed:", err)
+		return "", err
+	}
+
+	log.Println("✅ PDF "+name+":", len(sel), "pages →", out)
+	return out, nil
+}
@@ -0,0 +1,308 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Sizes are in points, as displayed (rotation applied)
+type inspectPage struct {
+	Page     int     `json:"page"`
+	Width    float64 `json:"width"`
+	Height   float64 `json:"height"`
+	Rotation int     `json:"rotation"`
+	Images   int     `json:"images"`
+	HasText  bool    `json:"hasText"`
+}
+
+// A font that is not embedded is substituted by the viewer, so the
+// document may not look the same everywhere.
+type inspectFont struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Encoding string `json:"encoding,omitempty"`
+	Embedded bool   `json:"embedded"`
+	Subset   bool   `json:"subset"`
+}
+
+// Same names and values as the protect tool's options
+type inspectPermissions struct {
+	Print    string `json:"print"`
+	Modify   bool   `json:"modify"`
+	Extract  bool   `json:"extract"`
+	Annotate bool   `json:"annotate"`
+	Form     bool   `json:"form"`
+	Assemble bool   `json:"assemble"`
+}
+
+type inspectAttachment struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Returned by the inspect tool. When the file needs a password to open
+// and none was given, only Encrypted and PasswordRequired are set.
+type inspectReport struct {
+	Version          string              `json:"version,omitempty"`
+	PageCount        int                 `json:"pageCount"`
+	Encrypted        bool                `json:"encrypted"`
+	PasswordRequired bool                `json:"passwordRequired"`
+	Permissions      *inspectPermissions `json:"permissions,omitempty"`
+	Form             bool                `json:"form"`
+	Signatures       bool                `json:"signatures"`
+	JavaScript       bool                `json:"javascript"`
+	Tagged           bool                `json:"tagged"`
+	Linearized       bool                `json:"linearized"`
+	Bookmarks        bool                `json:"bookmarks"`
+	Images           int                 `json:"images"`
+	Fonts            []inspectFont       `json:"fonts"`
+	MissingFonts     []string            `json:"missingFonts"`
+	Attachments      []inspectAttachment `json:"attachments"`
+	TextPages        int                 `json:"textPages"`
+	Pages            []inspectPage       `json:"pages"`
+}
+
+// ----------------------------
+// READ (with optional password)
+// ----------------------------
+func readInspectContext(input, password string) (*model.Context, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	conf.Cmd = model.LISTINFO
+	conf.UserPW = password
+	conf.OwnerPW = password
+
+	return api.ReadAndValidate(f, conf)
+}
+
+// P entry of the encryption dictionary; bit n is 1 << (n-1). Revision 2
+// has no separate high quality print bit.
+func permissionsFromFlags(p, rev int) *inspectPermissions {
+	bit := func(n int) bool { return p&(1<<(n-1)) != 0 }
+
+	perms := &inspectPermissions{
+		Print:    "none",
+		Modify:   bit(4),
+		Extract:  bit(5),
+		Annotate: bit(6),
+		Form:     bit(6) || (rev >= 3 && bit(9)),
+		Assemble: bit(4) || (rev >= 3 && bit(11)),
+	}
+	if bit(3) {
+		perms.Print = "full"
+		if rev >= 3 && !bit(12) {
+			perms.Print = "low"
+		}
+	}
+	return perms
+}
+
+// ----------------------------
+// JAVASCRIPT
+// ----------------------------
+// Any JavaScript action (/S /JavaScript), /JS entry or document-level
+// JavaScript name tree. Actions are often direct objects inside an
+// annotation or the catalog, so every object is walked, not only the
+// top-level ones.
+func hasJavaScript(ctx *model.Context) bool {
+	var walk func(o types.Object, depth int) bool
+	walk = func(o types.Object, depth int) bool {
+		if depth > 32 {
+			return false
+		}
+		switch t := o.(type) {
+		case types.Dict:
+			if s, ok := t["S"].(types.Name); ok && s == "JavaScript" {
+				return true
+			}
+			if _, ok := t["JS"]; ok {
+				return true
+			}
+			for _, v := range t {
+				if walk(v, depth+1) {
+					return true
+				}
+			}
+		case types.StreamDict:
+			return walk(t.Dict, depth+1)
+		case types.Array:
+			for _, v := range t {
+				if walk(v, depth+1) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if names, err := ctx.DereferenceDict(ctx.RootDict["Names"]); err == nil && names != nil {
+		if _, ok := names["JavaScript"]; ok {
+			return true
+		}
+	}
+
+	for _, e := range ctx.Table {
+		if e == nil || e.Free || e.Object == nil {
+			continue
+		}
+		if walk(e.Object, 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// ----------------------------
+// TEXT LAYER
+// ----------------------------
+// pdftotext cannot open files with an open password, so those are
+// checked on a decrypted copy.
+func pagesWithText(input, password string, encrypted bool) (map[int]bool, error) {
+	src := input
+	if encrypted && password != "" {
+		src = TempName("inspect_decrypted", ".pdf")
+		defer DeleteFile(src)
+		if stderr, err := runQPDF("--decrypt", "--password="+password, input, src); err != nil {
+			return nil, fmt.Errorf("decrypt for text check failed: %v: %s", err, strings.TrimSpace(stderr))
+		}
+	}
+
+	pages, err := textLayout(src)
+	if err != nil {
+		return nil, err
+	}
+
+	text := map[int]bool{}
+	for i, p := range pages {
+		text[i+1] = strings.TrimSpace(p.text()) != ""
+	}
+	return text, nil
+}
+
+// ----------------------------
+// INSPECT
+// ----------------------------
+// opts["password"] optional, to look inside files that need one to open
+func inspectPDF(input string, opts map[string]string) (*inspectReport, error) {
+	password := opts["password"]
+
+	ctx, err := readInspectContext(input, password)
+	if errors.Is(err, pdfcpu.ErrWrongPassword) {
+		if password == "" {
+			// not a failure: the frontend learns it has to ask for one
+			log.Println("✅ PDF inspected: password required")
+			return &inspectReport{
+				Encrypted:        true,
+				PasswordRequired: true,
+				Fonts:            []inspectFont{},
+				MissingFonts:     []string{},
+				Attachments:      []inspectAttachment{},
+				Pages:            []inspectPage{},
+			}, nil
+		}
+		log.Println("❌ inspect: wrong password")
+		return nil, errWrongPassword
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+
+	// a second read without password tells whether one is needed to open
+	passwordRequired := false
+	if ctx.Encrypt != nil && password != "" {
+		_, err := readInspectContext(input, "")
+		passwordRequired = errors.Is(err, pdfcpu.ErrWrongPassword)
+	}
+
+	javascript := hasJavaScript(ctx)
+
+	// collects fonts and images per page
+	if err := api.OptimizeContext(ctx); err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+
+	info, err := pdfcpu.Info(ctx, "", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+
+	report := &inspectReport{
+		Version:          info.Version,
+		PageCount:        ctx.PageCount,
+		Encrypted:        info.Encrypted,
+		PasswordRequired: passwordRequired,
+		Form:             info.Form,
+		Signatures:       info.Signatures,
+		JavaScript:       javascript,
+		Tagged:           info.Tagged,
+		Linearized:       info.Linearized,
+		Bookmarks:        info.Outlines,
+		Images:           len(ctx.Optimize.ImageObjects),
+		Fonts:            []inspectFont{},
+		MissingFonts:     []string{},
+		Attachments:      []inspectAttachment{},
+		Pages:            []inspectPage{},
+	}
+
+	if ctx.E != nil {
+		report.Permissions = permissionsFromFlags(ctx.E.P, ctx.E.R)
+	}
+
+	missing := map[string]bool{}
+	for _, fi := range info.Fonts {
+		report.Fonts = append(report.Fonts, inspectFont{
+			Name:     fi.Name,
+			Type:     fi.Type,
+			Encoding: fi.Encoding,
+			Embedded: fi.Embedded,
+			Subset:   fi.Prefix != "",
+		})
+		if !fi.Embedded && !missing[fi.Name] {
+			missing[fi.Name] = true
+			report.MissingFonts = append(report.MissingFonts, fi.Name)
+		}
+	}
+
+	for _, a := range info.Attachments {
+		report.Attachments = append(report.Attachments, inspectAttachment{Name: a.FileName, Description: a.Desc})
+	}
+
+	text, err := pagesWithText(input, password, info.Encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("inspect: %w", err)
+	}
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		_, box, rot, err := visibleBox(ctx, p)
+		if err != nil {
+			return nil, fmt.Errorf("inspect: %w", err)
+		}
+		w, h := displaySize(box, rot)
+
+		page := inspectPage{Page: p, Width: w, Height: h, Rotation: rot, HasText: text[p]}
+		if p-1 < len(ctx.Optimize.PageImages) {
+			page.Images = len(ctx.Optimize.PageImages[p-1])
+		}
+		if page.HasText {
+			report.TextPages++
+		}
+		report.Pages = append(report.Pages, page)
+	}
+
+	log.Println("✅ PDF inspected:", report.PageCount, "pages,", report.TextPages, "with text")
+	return report, nil
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// one input of a merge, after its page selection is resolved
+type mergeSource struct {
+	Path   string
+	Title  string
+	Ranges []pageRange
+	Pages  int
+}
+
+// ----------------------------
+// SOURCES + PAGE RANGES
+// ----------------------------
+// Per-file page ranges are passed as options keyed by the file's index
+// in Job.Files: pages_0, pages_1, ... (same as the combine-worker).
+// Titles come from the uploaded file names, not the local copies.
+func loadMergeSources(files, names []string, opts map[string]string) ([]mergeSource, error) {
+	var sources []mergeSource
+
+	for i, f := range files {
+		total, err := pageCount(f)
+		if err != nil {
+			return nil, fmt.Errorf("file %d (%s): %w", i, names[i], err)
+		}
+
+		ranges := []pageRange{{1, total}}
+		if expr := opts[fmt.Sprintf("pages_%d", i)]; expr != "" {
+			ranges, err = parsePageRanges(expr, total)
+			if err != nil {
+				return nil, fmt.Errorf("file %d (%s): %w", i, names[i], err)
+			}
+		}
+
+		n := 0
+		for _, r := range ranges {
+			n += r.Len()
+		}
+
+		base := names[i]
+		sources = append(sources, mergeSource{
+			Path:   f,
+			Title:  strings.TrimSuffix(base, filepath.Ext(base)),
+			Ranges: ranges,
+			Pages:  n,
+		})
+	}
+
+	return sources, nil
+}
+
+// qpdf --empty --pages a.pdf 1-3 b.pdf 1-z -- out.pdf
+func qpdfMergeArgs(sources []mergeSource, out string) []string {
+	args := []string{"--empty", "--pages"}
+	for _, s := range sources {
+		args = append(args, s.Path, rangesToQPDF(s.Ranges))
+	}
+	return append(args, "--", out)
+}
+
+// ----------------------------
+// TABLE OF CONTENTS PAGE
+// ----------------------------
+const tocLinesPerPage = 36
+
+func tocPageCount(sources []mergeSource) int {
+	return (len(sources) + tocLinesPerPage - 1) / tocLinesPerPage
+}
+
+// Lists every source with the page it starts on in the merged file
+// (TOC pages included in the numbering).
+func writeTOC(out string, sources []mergeSource, width, height float64) error {
+	page := tocPageCount(sources) + 1
+
+	var pages [][]textLine
+	var lines []textLine
+
+	for i, s := range sources {
+		if i%tocLinesPerPage == 0 {
+			if lines != nil {
+				pages = append(pages, lines)
+			}
+			lines = []textLine{{Text: "Contents", Size: 18}, {Text: ""}}
+		}
+
+		lines = append(lines, textLine{
+			Text:  fmt.Sprintf("%d.  %s", i+1, s.Title),
+			Right: fmt.Sprintf("%d", page),
+		})
+		page += s.Pages
+	}
+	pages = append(pages, lines)
+
+	return writeTextPDF(out, pages, width, height)
+}
+
+// ----------------------------
+// BOOKMARKS
+// ----------------------------
+// One top-level bookmark per source file, replacing any outline qpdf
+// may have carried over.
+func addSourceBookmarks(input, out string, sources []mergeSource, tocPages int) error {
+	var bms []pdfcpu.Bookmark
+
+	if tocPages > 0 {
+		bms = append(bms, pdfcpu.Bookmark{Title: "Contents", PageFrom: 1})
+	}
+
+	page := tocPages + 1
+	for _, s := range sources {
+		bms = append(bms, pdfcpu.Bookmark{Title: s.Title, PageFrom: page})
+		page += s.Pages
+	}
+
+	return api.AddBookmarksFile(input, out, bms, true, nil)
+}
+
+// ----------------------------
+// PAGE SIZE NORMALIZATION
+// ----------------------------
+// Scales every page to fit the paper size, keeping each page's
+// orientation.
+func normalizePageSize(input, out, paper string) error {
+	res, err := pdfcpu.ParseResizeConfig("formsize:"+paper, types.POINTS)
+	if err != nil {
+		return err
+	}
+	return api.ResizeFile(input, out, nil, res, nil)
+}
+
+// ----------------------------
+// INTERLEAVE (double-sided scans)
+// ----------------------------
+// Alternates pages of two files: front 1, back 1, front 2, ...
+// Backs scanned by flipping the stack come out last-page-first, so the
+// second file is reversed unless opts["reverseSecond"] is "false".
+// If one file is longer its remaining pages are appended at the end.
+func interleavePDFs(files []string, opts map[string]string) (string, error) {
+	if len(files) != 2 {
+		return "", fmt.Errorf("interleave: needs exactly 2 files, got %d", len(files))
+	}
+	// the same scan picked for fronts and backs would duplicate every page
+	if same, err := sameContent(files[0], files[1]); err != nil {
+		return "", err
+	} else if same {
+		return "", fmt.Errorf("interleave: both files are identical")
+	}
+
+	fronts, err := pageCount(files[0])
+	if err != nil {
+		return "", err
+	}
+	backs, err := pageCount(files[1])
+	if err != nil {
+		return "", err
+	}
+
+	if fronts != backs {
+		log.Println("⚠️ interleave: page counts differ:", fronts, "fronts,", backs, "backs")
+	}
+
+	backOrder := "1-z"
+	if opts["reverseSecond"] != "false" {
+		backOrder = "z-1"
+	}
+
+	out := TempName("interleaved", ".pdf")
+
+	_, err = runQPDF("--collate", "--empty",
+		"--pages", files[0], "1-z", files[1], backOrder, "--",
+		out,
+	)
+	if err != nil {
+		log.Println("❌ qpdf interleave failed:", err)
+		return "", err
+	}
+
+	log.Println("✅ PDFs interleaved:", fronts+backs, "pages →", out)
+	return out, nil
+}
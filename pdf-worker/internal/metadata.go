@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Returned by the metadata tool in read mode
+type metadataReport struct {
+	Info map[string]string `json:"info"`
+	XMP  string            `json:"xmp,omitempty"`
+}
+
+// option → exiftool tags (Info dictionary + matching XMP property)
+var metadataTags = []struct {
+	Option string
+	Tags   []string
+}{
+	{"title", []string{"PDF:Title", "XMP-dc:Title"}},
+	{"author", []string{"PDF:Author", "XMP-dc:Creator"}},
+	{"subject", []string{"PDF:Subject", "XMP-dc:Description"}},
+	{"keywords", []string{"PDF:Keywords", "XMP-pdf:Keywords"}},
+	{"creator", []string{"PDF:Creator", "XMP-xmp:CreatorTool"}},
+	{"producer", []string{"PDF:Producer", "XMP-pdf:Producer"}},
+	{"creationDate", []string{"PDF:CreateDate", "XMP-xmp:CreateDate"}},
+	{"modDate", []string{"PDF:ModifyDate", "XMP-xmp:ModifyDate"}},
+}
+
+// ----------------------------
+// READ
+// ----------------------------
+func readMetadata(input string) (*metadataReport, error) {
+	ctx, err := api.ReadContextFile(input)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &metadataReport{Info: map[string]string{}}
+
+	if ctx.Info != nil {
+		d, err := ctx.DereferenceDict(*ctx.Info)
+		if err == nil {
+			for k, v := range d {
+				s, err := ctx.DereferenceStringOrHexLiteral(v, model.V10, nil)
+				if err == nil {
+					report.Info[k] = s
+				}
+			}
+		}
+	}
+
+	if sd, _, err := ctx.DereferenceStreamDict(ctx.RootDict["Metadata"]); err == nil && sd != nil {
+		if sd.Decode() == nil {
+			report.XMP = string(sd.Content)
+		}
+	}
+
+	return report, nil
+}
+
+// exiftool wants "2006:01:02 15:04:05-07:00"; we accept RFC 3339 or a
+// plain date
+func exifDate(v string) (string, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t.Format("2006:01:02 15:04:05-07:00"), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date %q (use RFC 3339, e.g. 2024-05-01T10:00:00Z)", v)
+}
+
+// exiftool updates PDFs incrementally, so the old values would still be
+// in the file. Rewriting with qpdf drops everything no longer referenced.
+func runExiftoolAndRewrite(input, out string, args []string) error {
+	tmp := TempName("metadata_tmp", ".pdf")
+	defer DeleteFile(tmp)
+
+	if err := copyFile(input, tmp); err != nil {
+		return err
+	}
+
+	args = append([]string{"-overwrite_original", "-q"}, args...)
+	args = append(args, tmp)
+
+	outBytes, err := exec.Command("exiftool", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("exiftool failed: %v: %s", err, strings.TrimSpace(string(outBytes)))
+	}
+
+	_, err = runQPDF(tmp, out)
+	return err
+}
+
+// ----------------------------
+// WRITE
+// ----------------------------
+// Only options that are present are changed; an empty value clears it.
+func writeMetadata(input string, opts map[string]string) (string, error) {
+	var args []string
+
+	for _, m := range metadataTags {
+		opt := m.Option
+		v, ok := opts[opt]
+		if !ok {
+			continue
+		}
+
+		if v != "" && (opt == "creationDate" || opt == "modDate") {
+			var err error
+			if v, err = exifDate(v); err != nil {
+				return "", fmt.Errorf("metadata %s: %w", opt, err)
+			}
+		}
+
+		for _, tag := range m.Tags {
+			args = append(args, "-"+tag+"="+v)
+		}
+	}
+
+	if len(args) == 0 {
+		return "", fmt.Errorf("metadata: nothing to write")
+	}
+
+	out := TempName("metadata", ".pdf")
+	if err := runExiftoolAndRewrite(input, out, args); err != nil {
+		return "", err
+	}
+
+	log.Println("✅ Metadata written:", out)
+	return out, nil
+}
+
+// ----------------------------
+// STRIP
+// ----------------------------
+func stripMetadata(input string) (string, error) {
+	out := TempName("stripped", ".pdf")
+
+	if err := runExiftoolAndRewrite(input, out, []string{"-all:all="}); err != nil {
+		return "", err
+	}
+
+	log.Println("✅ Metadata stripped:", out)
+	return out, nil
+}
+
+// ----------------------------
+// METADATA TOOL
+// ----------------------------
+// opts["mode"] read (default) | write | strip
+// write: title, author, subject, keywords, creator, producer, creationDate,
+// modDate (dates as RFC 3339 or YYYY-MM-DD)
+func metadataPDF(input string, opts map[string]string) (string, *metadataReport, error) {
+	switch opts["mode"] {
+
+	case "", "read":
+		report, err := readMetadata(input)
+		return "", report, err
+
+	case "write":
+		out, err := writeMetadata(input, opts)
+		return out, nil, err
+
+	case "strip":
+		out, err := stripMetadata(input)
+		return out, nil, err
+	}
+
+	return "", nil, fmt.Errorf("metadata: unknown mode %q", opts["mode"])
+}
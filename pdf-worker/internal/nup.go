@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// pages per sheet we offer; pdfcpu knows a few more layouts
+var nupValues = []int{2, 4, 6, 9, 16}
+
+// saddle-stitch: 2 pages per sheet side (4 per folded sheet) or 4
+var bookletValues = []int{2, 4}
+
+// ----------------------------
+// OPTIONS → pdfcpu description
+// ----------------------------
+// Builds pdfcpu's "formsize:A4L, margin:10, border:on" string.
+// orientation is the sheet's; without it we let pdfcpu decide (portrait)
+// except for 2-up and 6-up, whose pages fit a landscape sheet better.
+func nupDescription(n int, opts map[string]string, booklet bool) (string, error) {
+	var parts []string
+
+	paper := opts["paper"]
+	if paper == "" {
+		paper = "A4"
+	}
+	if name, ok := paperName(paper); ok {
+		paper = name
+	}
+
+	switch strings.ToLower(opts["orientation"]) {
+	case "landscape":
+		paper += "L"
+	case "portrait":
+		paper += "P"
+	case "":
+		if !booklet && (n == 2 || n == 6) {
+			paper += "L"
+		}
+	default:
+		return "", fmt.Errorf("nup: orientation must be portrait or landscape")
+	}
+	parts = append(parts, "formsize:"+paper)
+
+	if m := opts["margin"]; m != "" {
+		v, err := strconv.ParseFloat(m, 64)
+		if err != nil || v < 0 {
+			return "", fmt.Errorf("nup: invalid margin %q (points)", m)
+		}
+		parts = append(parts, "margin:"+m)
+	}
+
+	if b := opts["border"]; b != "" {
+		on, err := parseYesNo(b)
+		if err != nil {
+			return "", fmt.Errorf("nup: border: %w", err)
+		}
+		parts = append(parts, "border:"+onOff(on))
+	}
+
+	if booklet {
+		if b := strings.ToLower(opts["binding"]); b != "" {
+			if b != "long" && b != "short" {
+				return "", fmt.Errorf("booklet: binding must be long or short")
+			}
+			parts = append(parts, "binding:"+b)
+		}
+		if g := opts["guides"]; g != "" {
+			on, err := parseYesNo(g)
+			if err != nil {
+				return "", fmt.Errorf("booklet: guides: %w", err)
+			}
+			parts = append(parts, "guides:"+onOff(on))
+		}
+	} else if o := strings.ToLower(opts["order"]); o != "" {
+		// rd = right then down (reading order), dr, ld, dl
+		switch o {
+		case "rd", "dr", "ld", "dl":
+			parts = append(parts, "orientation:"+o)
+		default:
+			return "", fmt.Errorf("nup: order must be rd, dr, ld or dl")
+		}
+	}
+
+	return strings.Join(parts, ", "), nil
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+func parseNupValue(opts map[string]string, allowed []int, def int) (int, error) {
+	if opts["n"] == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(opts["n"])
+	if err == nil {
+		for _, v := range allowed {
+			if n == v {
+				return n, nil
+			}
+		}
+	}
+
+	var ss []string
+	for _, v := range allowed {
+		ss = append(ss, strconv.Itoa(v))
+	}
+	return 0, fmt.Errorf("n must be one of %s, got %q", strings.Join(ss, ", "), opts["n"])
+}
+
+// ----------------------------
+// NUP TOOL
+// ----------------------------
+// opts["mode"]        nup (default) | booklet
+// opts["n"]           nup: 2, 4, 6, 9, 16 (default 2); booklet: 2 (default), 4
+// opts["paper"]       A4 (default), Letter, or any pdfcpu paper name
+// opts["orientation"] portrait | landscape
+// opts["margin"]      space around each page, in points
+// opts["border"]      yes | no
+// opts["order"]       nup only: rd (default), dr, ld, dl
+// opts["binding"]     booklet only: long (default) | short
+// opts["guides"]      booklet only: print fold and cut lines
+//
+// Booklets reorder the pages for saddle-stitch printing and pad the end
+// with blank pages up to a whole number of sheets.
+func nupPDF(input string, opts map[string]string) (string, error) {
+	tool := "nup"
+	allowed := nupValues
+
+	switch opts["mode"] {
+	case "", "nup":
+	case "booklet":
+		tool = "booklet"
+		allowed = bookletValues
+	default:
+		return "", fmt.Errorf("nup: unknown mode %q", opts["mode"])
+	}
+	booklet := tool == "booklet"
+
+	n, err := parseNupValue(opts, allowed, 2)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", tool, err)
+	}
+
+	desc, err := nupDescription(n, opts, booklet)
+	if err != nil {
+		return "", err
+	}
+
+	var nup *model.NUp
+	if booklet {
+		nup, err = api.PDFBookletConfig(n, desc, nil)
+	} else {
+		nup, err = api.PDFNUpConfig(n, desc, nil)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", tool, err)
+	}
+
+	out := TempName(tool, ".pdf")
+	if booklet {
+		err = api.BookletFile([]string{input}, out, nil, nup, nil)
+	} else {
+		err = api.NUpFile([]string{input}, out, nil, nup, nil)
+	}
+	if err != nil {
+		log.Println("❌ "+tool+" failed:", err)
+		return "", err
+	}
+
+	log.Println("✅ PDF imposed ("+tool+",", n, "up):", out)
+	return out, nil
+}
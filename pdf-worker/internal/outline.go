@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// One outline entry as sent in opts["outline"] and returned by export
+type outlineItem struct {
+	Title  string        `json:"title"`
+	Page   int           `json:"page"`
+	Bold   bool          `json:"bold,omitempty"`
+	Italic bool          `json:"italic,omitempty"`
+	Kids   []outlineItem `json:"kids,omitempty"`
+}
+
+// Export result; also accepted as input so an exported outline can be
+// edited and sent back as is
+type outlineReport struct {
+	Pages     int           `json:"pages"`
+	Bookmarks []outlineItem `json:"bookmarks"`
+}
+
+// ----------------------------
+// JSON <-> pdfcpu
+// ----------------------------
+// Accepts either a plain array of items or {"bookmarks": [...]}
+func parseOutline(raw string) ([]outlineItem, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("outline: missing outline option")
+	}
+
+	var items []outlineItem
+	if strings.HasPrefix(raw, "[") {
+		if err := json.Unmarshal([]byte(raw), &items); err != nil {
+			return nil, fmt.Errorf("outline: invalid JSON: %w", err)
+		}
+	} else {
+		var tree outlineReport
+		if err := json.Unmarshal([]byte(raw), &tree); err != nil {
+			return nil, fmt.Errorf("outline: invalid JSON: %w", err)
+		}
+		items = tree.Bookmarks
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("outline: no bookmarks given")
+	}
+	return items, nil
+}
+
+func toBookmarks(items []outlineItem) []pdfcpu.Bookmark {
+	var bms []pdfcpu.Bookmark
+	for _, it := range items {
+		bms = append(bms, pdfcpu.Bookmark{
+			Title:    it.Title,
+			PageFrom: it.Page,
+			Bold:     it.Bold,
+			Italic:   it.Italic,
+			Kids:     toBookmarks(it.Kids),
+		})
+	}
+	return bms
+}
+
+func fromBookmarks(bms []pdfcpu.Bookmark) []outlineItem {
+	var items []outlineItem
+	for _, bm := range bms {
+		items = append(items, outlineItem{
+			Title:  bm.Title,
+			Page:   bm.PageFrom,
+			Bold:   bm.Bold,
+			Italic: bm.Italic,
+			Kids:   fromBookmarks(bm.Kids),
+		})
+	}
+	return items
+}
+
+// ----------------------------
+// VALIDATION
+// ----------------------------
+// PDF viewers expect siblings in page order and children starting at or
+// after their parent; pdfcpu rejects anything else with a generic error,
+// so check here and say which entry is wrong.
+func validateOutline(items []outlineItem, total, parentPage int, path string) error {
+	prev := parentPage
+
+	for i, it := range items {
+		where := fmt.Sprintf("%s%d", path, i+1)
+
+		if strings.TrimSpace(it.Title) == "" {
+			return fmt.Errorf("outline: bookmark %s has no title", where)
+		}
+		if it.Page < 1 || it.Page > total {
+			return fmt.Errorf("outline: bookmark %s (%q) points to page %d, document has %d pages",
+				where, it.Title, it.Page, total)
+		}
+		if it.Page < prev {
+			if i == 0 {
+				return fmt.Errorf("outline: bookmark %s (%q) is on page %d, before its parent (page %d)",
+					where, it.Title, it.Page, parentPage)
+			}
+			return fmt.Errorf("outline: bookmark %s (%q) is on page %d, before the previous bookmark (page %d)",
+				where, it.Title, it.Page, prev)
+		}
+		prev = it.Page
+
+		if err := validateOutline(it.Kids, total, it.Page, where+"."); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ----------------------------
+// EXPORT
+// ----------------------------
+func exportOutline(input string) (*outlineReport, error) {
+	total, err := pageCount(input)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bms, err := api.Bookmarks(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("outline: cannot read bookmarks: %w", err)
+	}
+
+	report := &outlineReport{Pages: total, Bookmarks: fromBookmarks(bms)}
+	if report.Bookmarks == nil {
+		report.Bookmarks = []outlineItem{}
+	}
+	return report, nil
+}
+
+// ----------------------------
+// OUTLINE TOOL
+// ----------------------------
+// opts["mode"]    replace (default) | add | export | remove
+// opts["outline"] JSON tree: [{"title": "...", "page": 1, "kids": [...]}]
+//
+// add keeps the existing outline and slots the new top-level entries in
+// by page number.
+func outlinePDF(input string, opts map[string]string) (string, *outlineReport, error) {
+	mode := opts["mode"]
+	if mode == "" {
+		mode = "replace"
+	}
+
+	switch mode {
+
+	case "export":
+		report, err := exportOutline(input)
+		return "", report, err
+
+	case "remove":
+		out := TempName("outline", ".pdf")
+		if err := api.RemoveBookmarksFile(input, out, nil); err != nil {
+			log.Println("❌ Remove bookmarks failed:", err)
+			return "", nil, err
+		}
+		log.Println("✅ Outline removed:", out)
+		return out, nil, nil
+
+	case "replace", "add":
+
+	default:
+		return "", nil, fmt.Errorf("outline: unknown mode %q", mode)
+	}
+
+	items, err := parseOutline(opts["outline"])
+	if err != nil {
+		return "", nil, err
+	}
+
+	if mode == "add" {
+		existing, err := exportOutline(input)
+		if err != nil {
+			return "", nil, err
+		}
+		items = append(existing.Bookmarks, items...)
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Page < items[j].Page })
+	}
+
+	total, err := pageCount(input)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := validateOutline(items, total, 1, ""); err != nil {
+		return "", nil, err
+	}
+
+	out := TempName("outline", ".pdf")
+	if err := api.AddBookmarksFile(input, out, toBookmarks(items), true, nil); err != nil {
+		log.Println("❌ Writing outline failed:", err)
+		return "", nil, err
+	}
+
+	log.Println("✅ Outline written:", len(items), "top-level bookmarks →", out)
+	return out, nil, nil
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// pageRange is an inclusive, 1-based span of pages. From > To is a
+// reversed range ("5-3" is pages 5, 4, 3).
+type pageRange struct {
+	From int
+	To   int
+}
+
+// Pages in the range, in the order given
+func (r pageRange) Pages() []int {
+	step := 1
+	if r.From > r.To {
+		step = -1
+	}
+	pages := []int{r.From}
+	for p := r.From; p != r.To; {
+		p += step
+		pages = append(pages, p)
+	}
+	return pages
+}
+
+// Number of pages in the range
+func (r pageRange) Len() int {
+	if r.From > r.To {
+		return r.From - r.To + 1
+	}
+	return r.To - r.From + 1
+}
+
+func (r pageRange) String() string {
+	if r.From == r.To {
+		return strconv.Itoa(r.From)
+	}
+	return fmt.Sprintf("%d-%d", r.From, r.To)
+}
+
+// ----------------------------
+// PAGE RANGE PARSING
+// ----------------------------
+// Parses expressions like "1-3,5,8-" against a document of `total` pages.
+// "N-" runs to the last page, "-N" starts at page 1. "last" (or "z") is
+// the last page and "rN" counts from the end, so "r1" is the last page
+// and "r3-r1" the last three. A range whose start is after its end runs
+// backwards, like in qpdf: "5-3", "z-1" and "r1-1" are reversed.
+// Every page number is checked against `total` so callers get a readable
+// error instead of a qpdf failure.
+func parsePageRanges(expr string, total int) ([]pageRange, error) {
+	expr = strings.ReplaceAll(expr, " ", "")
+	if expr == "" {
+		return nil, errors.New("empty page range")
+	}
+
+	var ranges []pageRange
+
+	for _, part := range strings.Split(expr, ",") {
+		if part == "" {
+			continue
+		}
+
+		from, to := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			from, to = part[:i], part[i+1:]
+			if from == "" {
+				from = "1"
+			}
+			if to == "" {
+				to = strconv.Itoa(total)
+			}
+		}
+
+		f, err := parsePageNumber(from, total)
+		if err != nil {
+			return nil, err
+		}
+		t, err := parsePageNumber(to, total)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, pageRange{From: f, To: t})
+	}
+
+	if len(ranges) == 0 {
+		return nil, errors.New("empty page range")
+	}
+
+	return ranges, nil
+}
+
+func parsePageNumber(s string, total int) (int, error) {
+	s = strings.ToLower(s)
+
+	var n int
+	var err error
+
+	switch {
+	case s == "last" || s == "z":
+		n = total
+
+	case strings.HasPrefix(s, "r"):
+		n, err = strconv.Atoi(s[1:])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid page number %q", s)
+		}
+		if n > total {
+			return 0, fmt.Errorf("page %s is out of range (document has %d pages)", s, total)
+		}
+		n = total - n + 1
+
+	default:
+		n, err = strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid page number %q", s)
+		}
+	}
+
+	if n < 1 || n > total {
+		return 0, fmt.Errorf("page %d is out of range (document has %d pages)", n, total)
+	}
+	return n, nil
+}
+
+// qpdf page selection string for a list of ranges, e.g. "1-3,5"
+func rangesToQPDF(ranges []pageRange) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// ----------------------------
+// QPDF HELPERS
+// ----------------------------
+// qpdf exits with 3 when it succeeded but had to work around problems
+func isQPDFWarning(err error) bool {
+	exitErr, ok := err.(*exec.ExitError)
+	return ok && exitErr.ExitCode() == 3
+}
+
+// Runs qpdf, treating warnings as success. Returns whatever qpdf printed
+// on stderr (the warnings, if any).
+func runQPDF(args ...string) (string, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("qpdf", args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil && !isQPDFWarning(err) {
+		return stderr.String(), fmt.Errorf("qpdf failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stderr.String(), nil
+}
+
+func pageCount(input string) (int, error) {
+	out, err := exec.Command("qpdf", "--show-npages", input).Output()
+	if err != nil && !isQPDFWarning(err) {
+		return 0, fmt.Errorf("qpdf could not read page count: %v", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// Writes the selected pages of input (in the given order) to out.
+func writePages(input, out string, ranges []pageRange) error {
+	_, err := runQPDF(input, out, "--pages", ".", rangesToQPDF(ranges), "--")
+	return err
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageRanges(t *testing.T) {
+	tests := []struct {
+		expr  string
+		total int
+		want  []pageRange
+	}{
+		{"1", 5, []pageRange{{1, 1}}},
+		{"1-3,5", 5, []pageRange{{1, 3}, {5, 5}}},
+		{"2-", 5, []pageRange{{2, 5}}},
+		{"-3", 5, []pageRange{{1, 3}}},
+		{" 1 - 2 , 4 ", 5, []pageRange{{1, 2}, {4, 4}}},
+		{"1,,3", 5, []pageRange{{1, 1}, {3, 3}}},
+		{"last", 5, []pageRange{{5, 5}}},
+		{"z", 5, []pageRange{{5, 5}}},
+		{"r1", 5, []pageRange{{5, 5}}},
+		{"r3-r1", 5, []pageRange{{3, 5}}},
+		{"r2-last", 5, []pageRange{{4, 5}}},
+		{"3-3", 5, []pageRange{{3, 3}}},
+		{"5-3", 5, []pageRange{{5, 3}}},
+		{"z-1", 5, []pageRange{{5, 1}}},
+		{"r1-1", 5, []pageRange{{5, 1}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePageRanges(tt.expr, tt.total)
+		if err != nil {
+			t.Errorf("parsePageRanges(%q, %d): %v", tt.expr, tt.total, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePageRanges(%q, %d) = %v, want %v", tt.expr, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestParsePageRangesInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		" ",
+		",",
+		"0",
+		"6",
+		"1-6",
+		"r6",
+		"r0",
+		"x",
+		"1-x",
+		"-1-2",
+	}
+
+	for _, expr := range tests {
+		if got, err := parsePageRanges(expr, 5); err == nil {
+			t.Errorf("parsePageRanges(%q, 5) = %v, expected an error", expr, got)
+		}
+	}
+}
+
+func TestParsePageNumber(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int
+		wantErr bool
+	}{
+		{"1", 1, false},
+		{"10", 10, false},
+		{"last", 10, false},
+		{"LAST", 10, false},
+		{"z", 10, false},
+		{"Z", 10, false},
+		{"r1", 10, false},
+		{"R3", 8, false},
+		{"r10", 1, false},
+		{"0", 0, true},
+		{"11", 0, true},
+		{"-1", 0, true},
+		{"r0", 0, true},
+		{"r11", 0, true},
+		{"r", 0, true},
+		{"rx", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePageNumber(tt.s, 10)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePageNumber(%q, 10) = %d, expected an error", tt.s, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePageNumber(%q, 10): %v", tt.s, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePageNumber(%q, 10) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPageRangePages(t *testing.T) {
+	tests := []struct {
+		r    pageRange
+		want []int
+	}{
+		{pageRange{3, 3}, []int{3}},
+		{pageRange{1, 4}, []int{1, 2, 3, 4}},
+		{pageRange{5, 3}, []int{5, 4, 3}},
+	}
+
+	for _, tt := range tests {
+		got := tt.r.Pages()
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%v.Pages() = %v, want %v", tt.r, got, tt.want)
+		}
+		if tt.r.Len() != len(tt.want) {
+			t.Errorf("%v.Len() = %d, want %d", tt.r, tt.r.Len(), len(tt.want))
+		}
+	}
+}
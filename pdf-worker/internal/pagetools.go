@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ----------------------------
+// EXTRACT PAGES
+// ----------------------------
+// opts["pages"] e.g. "1-3,7,r2-last"; pages come out in the order given
+func extractPages(input string, opts map[string]string) (string, error) {
+	total, err := pageCount(input)
+	if err != nil {
+		return "", err
+	}
+
+	ranges, err := parsePageRanges(opts["pages"], total)
+	if err != nil {
+		return "", fmt.Errorf("extract-pages: %w", err)
+	}
+
+	out := TempName("extracted", ".pdf")
+	if err := writePages(input, out, ranges); err != nil {
+		return "", err
+	}
+
+	log.Println("✅ Pages extracted:", rangesToQPDF(ranges))
+	return out, nil
+}
+
+// ----------------------------
+// DELETE PAGES
+// ----------------------------
+// opts["pages"] e.g. "2,5-7,last"; everything else is kept in order
+func deletePages(input string, opts map[string]string) (string, error) {
+	total, err := pageCount(input)
+	if err != nil {
+		return "", err
+	}
+
+	ranges, err := parsePageRanges(opts["pages"], total)
+	if err != nil {
+		return "", fmt.Errorf("delete-pages: %w", err)
+	}
+
+	deleted := make([]bool, total+1)
+	for _, r := range ranges {
+		for _, p := range r.Pages() {
+			deleted[p] = true
+		}
+	}
+
+	keep := keptRanges(deleted, total)
+	if len(keep) == 0 {
+		return "", errors.New("delete-pages: cannot delete every page")
+	}
+
+	out := TempName("deleted", ".pdf")
+	if err := writePages(input, out, keep); err != nil {
+		return "", err
+	}
+
+	log.Println("✅ Pages deleted:", rangesToQPDF(ranges))
+	return out, nil
+}
+
+// Collapses the pages not marked in `removed` into consecutive ranges
+func keptRanges(removed []bool, total int) []pageRange {
+	var keep []pageRange
+	for p := 1; p <= total; p++ {
+		if removed[p] {
+			continue
+		}
+		if n := len(keep); n > 0 && keep[n-1].To == p-1 {
+			keep[n-1].To = p
+		} else {
+			keep = append(keep, pageRange{p, p})
+		}
+	}
+	return keep
+}
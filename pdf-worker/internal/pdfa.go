@@ -0,0 +1,289 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// PDF/A level → Ghostscript -dPDFA part and PDF compatibility level
+var pdfaLevels = map[string]struct {
+	Part          string
+	Compatibility string
+}{
+	"1b": {"1", "1.4"},
+	"2b": {"2", "1.7"},
+	"3b": {"3", "1.7"},
+}
+
+type pdfaCheck struct {
+	Rule   string `json:"rule"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Conformance report stored with the job result. Only veraPDF can say
+// a file is compliant; the basic checks leave Compliant unset and report
+// BasicChecksPassed instead.
+type pdfaReport struct {
+	Level             string      `json:"level"`
+	Validator         string      `json:"validator"`
+	Compliant         *bool       `json:"compliant,omitempty"`
+	BasicChecksPassed *bool       `json:"basicChecksPassed,omitempty"`
+	Checks            []pdfaCheck `json:"checks"`
+}
+
+// All checks passed, whichever validator ran
+func (r *pdfaReport) passed() bool {
+	if r.Compliant != nil {
+		return *r.Compliant
+	}
+	return r.BasicChecksPassed != nil && *r.BasicChecksPassed
+}
+
+// sRGB profile shipped with Ubuntu's ghostscript package
+func pdfaICCProfile() string {
+	if p := os.Getenv("PDFA_ICC_PROFILE"); p != "" {
+		return p
+	}
+	return "/usr/share/color/icc/ghostscript/srgb.icc"
+}
+
+// ----------------------------
+// PDFA_def.ps (output intent)
+// ----------------------------
+// Ghostscript needs a PostScript prefix that embeds the ICC profile and
+// declares it as the document's output intent.
+func writePDFADef(path, iccProfile string) error {
+	escaped := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(iccProfile)
+
+	def := `%!
+/ICCProfile (` + escaped + `) def
+[/_objdef {icc_PDFA} /type /stream /OBJ pdfmark
+[{icc_PDFA} << /N 3 >> /PUT pdfmark
+[{icc_PDFA} ICCProfile (r) file /PUT pdfmark
+[/_objdef {OutputIntent_PDFA} /type /dict /OBJ pdfmark
+[{OutputIntent_PDFA} <<
+  /Type /OutputIntent
+  /S /GTS_PDFA1
+  /DestOutputProfile {icc_PDFA}
+  /OutputConditionIdentifier (sRGB IEC61966-2.1)
+  /Info (sRGB IEC61966-2.1)
+>> /PUT pdfmark
+[{Catalog} << /OutputIntents [ {OutputIntent_PDFA} ] >> /PUT pdfmark
+`
+	return os.WriteFile(path, []byte(def), 0644)
+}
+
+func runGhostscriptPDFA(input, out, level string) error {
+	lv := pdfaLevels[level]
+	icc := pdfaICCProfile()
+
+	workDir, err := os.MkdirTemp("/tmp", "pdfa_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	def := filepath.Join(workDir, "PDFA_def.ps")
+	if err := writePDFADef(def, icc); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("gs",
+		"-dPDFA="+lv.Part,
+		"-dBATCH", "-dNOPAUSE", "-dNOOUTERSAVE", "-dQUIET",
+		"-sDEVICE=pdfwrite",
+		"-dCompatibilityLevel="+lv.Compatibility,
+		"-dPDFACompatibilityPolicy=1",
+		"-sColorConversionStrategy=RGB",
+		"-sProcessColorModel=DeviceRGB",
+		"-dEmbedAllFonts=true",
+		"--permit-file-read="+icc,
+		"-sOutputFile="+out,
+		def,
+		input,
+	)
+
+	outBytes, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ghostscript PDF/A failed: %v: %s", err, string(outBytes))
+	}
+	return nil
+}
+
+// ----------------------------
+// VALIDATION
+// ----------------------------
+// Uses veraPDF when it is installed, otherwise falls back to checking
+// a few main PDF/A requirements ourselves. Passing those does not make a
+// file compliant, so the fallback never sets Compliant.
+func validatePDFA(file, level string) *pdfaReport {
+	if path, err := exec.LookPath("verapdf"); err == nil {
+		report, err := validateWithVeraPDF(path, file, level)
+		if err == nil {
+			return report
+		}
+		log.Println("⚠️ veraPDF failed, using basic checks:", err)
+	}
+
+	return validatePDFABasic(file, level)
+}
+
+// minimal view of veraPDF's machine readable report (--format mrr)
+type veraReport struct {
+	Jobs []struct {
+		Validation struct {
+			IsCompliant bool `xml:"isCompliant,attr"`
+			Rules       []struct {
+				Clause       string `xml:"clause,attr"`
+				TestNumber   string `xml:"testNumber,attr"`
+				Status       string `xml:"status,attr"`
+				FailedChecks int    `xml:"failedChecks,attr"`
+				Description  string `xml:"description"`
+			} `xml:"details>rule"`
+		} `xml:"validationReport"`
+	} `xml:"jobs>job"`
+}
+
+func validateWithVeraPDF(path, file, level string) (*pdfaReport, error) {
+	var stdout bytes.Buffer
+
+	cmd := exec.Command(path, "--flavour", level, "--format", "mrr", file)
+	cmd.Stdout = &stdout
+
+	// veraPDF exits non-zero for non-compliant files, the XML is still valid
+	runErr := cmd.Run()
+
+	var vr veraReport
+	if err := xml.Unmarshal(stdout.Bytes(), &vr); err != nil || len(vr.Jobs) == 0 {
+		return nil, fmt.Errorf("unreadable veraPDF output (%v)", runErr)
+	}
+
+	v := vr.Jobs[0].Validation
+	compliant := v.IsCompliant
+	report := &pdfaReport{Level: level, Validator: "veraPDF", Compliant: &compliant}
+
+	for _, r := range v.Rules {
+		report.Checks = append(report.Checks, pdfaCheck{
+			Rule:   "clause " + r.Clause + " test " + r.TestNumber,
+			Passed: r.Status == "passed",
+			Detail: fmt.Sprintf("%s (%d failed checks)", strings.TrimSpace(r.Description), r.FailedChecks),
+		})
+	}
+
+	return report, nil
+}
+
+func validatePDFABasic(file, level string) *pdfaReport {
+	passed := true
+	report := &pdfaReport{Level: level, Validator: "basic", BasicChecksPassed: &passed}
+
+	add := func(rule string, ok bool, detail string) {
+		report.Checks = append(report.Checks, pdfaCheck{Rule: rule, Passed: ok, Detail: detail})
+		if !ok {
+			passed = false
+		}
+	}
+
+	ctx, err := api.ReadContextFile(file)
+	if err != nil {
+		add("readable", false, err.Error())
+		return report
+	}
+
+	add("not encrypted", ctx.Encrypt == nil, "")
+
+	// Output intent with an embedded ICC profile
+	intent := false
+	if arr, err := ctx.DereferenceArray(ctx.RootDict["OutputIntents"]); err == nil {
+		for _, o := range arr {
+			d, err := ctx.DereferenceDict(o)
+			if err != nil || d == nil {
+				continue
+			}
+			if s, ok := d["S"].(types.Name); ok && s == "GTS_PDFA1" && d["DestOutputProfile"] != nil {
+				intent = true
+			}
+		}
+	}
+	add("output intent (GTS_PDFA1 + ICC profile)", intent, "")
+
+	// XMP identification: pdfaid:part must match the requested level
+	part := pdfaLevels[level].Part
+	xmp := ""
+	if sd, _, err := ctx.DereferenceStreamDict(ctx.RootDict["Metadata"]); err == nil && sd != nil {
+		if sd.Decode() == nil {
+			xmp = string(sd.Content)
+		}
+	}
+	add("XMP pdfaid:part="+part,
+		strings.Contains(xmp, `pdfaid:part="`+part+`"`) || strings.Contains(xmp, "<pdfaid:part>"+part+"</pdfaid:part>"),
+		"")
+
+	// Every font must be embedded
+	f, err := os.Open(file)
+	if err != nil {
+		add("fonts embedded", false, err.Error())
+		return report
+	}
+	defer f.Close()
+
+	info, err := api.PDFInfo(f, file, nil, true, nil)
+	if err != nil {
+		add("fonts embedded", false, err.Error())
+		return report
+	}
+
+	var missing []string
+	for _, font := range info.Fonts {
+		if !font.Embedded {
+			missing = append(missing, font.Name)
+		}
+	}
+	add("fonts embedded", len(missing) == 0, strings.Join(missing, ", "))
+
+	return report
+}
+
+// ----------------------------
+// PDF/A CONVERSION
+// ----------------------------
+// opts["level"] 1b | 2b (default) | 3b
+func convertToPDFA(input string, opts map[string]string) (string, *pdfaReport, error) {
+	level := strings.ToLower(opts["level"])
+	if level == "" {
+		level = "2b"
+	}
+	if _, ok := pdfaLevels[level]; !ok {
+		return "", nil, fmt.Errorf("pdfa: unsupported level %q (use 1b, 2b or 3b)", level)
+	}
+
+	out := TempName("pdfa_"+level, ".pdf")
+
+	if err := runGhostscriptPDFA(input, out, level); err != nil {
+		log.Println("❌", err)
+		return "", nil, err
+	}
+
+	report := validatePDFA(out, level)
+
+	// a file that fails validation must not be handed out as PDF/A; the
+	// report is kept with the error to say which rules failed
+	if !report.passed() {
+		DeleteFile(out)
+		log.Println("❌ PDF/A-"+level, "validation failed ("+report.Validator+")")
+		return "", report, fmt.Errorf("pdfa: result is not PDF/A-%s compliant (%s)", level, report.Validator)
+	}
+
+	log.Println("✅ PDF/A-"+level, "created and validated ("+report.Validator+"):", out)
+	return out, report, nil
+}
@@ -1,149 +1,475 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // ----------------------------
 // MERGE PDFs
 // ----------------------------
-func mergePDFs(files []string) string {
+// opts["pages_<i>"]  page range for the i-th file (0-based), e.g. "1-3,5"
+// opts["bookmarks"] "false" to skip the per-file bookmarks
+// opts["toc"]       "true" to insert a table of contents page first
+// opts["pageSize"]  A4 | Letter to scale every page to one paper size
+func mergePDFs(files, names []string, opts map[string]string) (string, error) {
+	sources, err := loadMergeSources(files, names, opts)
+	if err != nil {
+		return "", fmt.Errorf("merge: %w", err)
+	}
+
+	paper := ""
+	if v := opts["pageSize"]; v != "" {
+		var ok bool
+		if paper, ok = paperName(v); !ok {
+			return "", fmt.Errorf("merge: unsupported page size %q", v)
+		}
+	}
+
+	var tmp []string
+	defer func() {
+		for _, t := range tmp {
+			DeleteFile(t)
+		}
+	}()
+
+	// 1. Optional table of contents, merged in as the first "file"
+	parts := sources
+	tocPages := 0
+
+	if opts["toc"] == "true" {
+		size := paperSizes["A4"]
+		if paper != "" {
+			size = paperSizes[paper]
+		}
+
+		toc := TempName("toc", ".pdf")
+		tmp = append(tmp, toc)
+
+		if err := writeTOC(toc, sources, size[0], size[1]); err != nil {
+			return "", fmt.Errorf("merge: toc: %w", err)
+		}
+
+		tocPages = tocPageCount(sources)
+		parts = append([]mergeSource{{Path: toc, Ranges: []pageRange{{1, tocPages}}}}, sources...)
+	}
+
+	// 2. Concatenate the selected pages
+	current := TempName("merged_raw", ".pdf")
+	tmp = append(tmp, current)
+
+	if _, err := runQPDF(qpdfMergeArgs(parts, current)...); err != nil {
+		log.Println("❌ qpdf merge failed:", err)
+		return "", err
+	}
+
+	// 3. Optional page size normalization
+	if paper != "" {
+		resized := TempName("merged_resized", ".pdf")
+		tmp = append(tmp, resized)
+
+		if err := normalizePageSize(current, resized, paper); err != nil {
+			return "", fmt.Errorf("merge: resize to %s: %w", paper, err)
+		}
+		current = resized
+	}
+
 	out := TempName("merged", ".pdf")
-	args := append(files, out)
 
-	if exec.Command("pdfunite", args...).Run() == nil {
-		return out
+	// 4. One bookmark per source file
+	if opts["bookmarks"] == "false" {
+		if err := copyFile(current, out); err != nil {
+			return "", err
+		}
+	} else if err := addSourceBookmarks(current, out, sources, tocPages); err != nil {
+		return "", fmt.Errorf("merge: bookmarks: %w", err)
 	}
 
-	log.Println("❌ pdfunite missing or failed, cannot merge")
-	return ""
+	log.Println("✅ PDFs merged:", len(sources), "files →", out)
+	return out, nil
 }
 
 // ----------------------------
 // SPLIT PDF
 // ----------------------------
+// opts["mode"]:
+//   ""          one file per page (default)
+//   "ranges"    opts["ranges"] = "1-3,4-10,11-"
+//   "every"     opts["every"] = pages per file
+//   "bookmarks" one file per top-level bookmark
+//   "size"      opts["maxSizeMB"] = maximum size of each file
 func splitPDF(input string, opts map[string]string) []string {
-	outPattern := TempName("split", "-%02d.pdf")
+	mode := opts["mode"]
 
-	if exec.Command("pdfseparate", input, outPattern).Run() != nil {
-		log.Println("❌ pdfseparate missing or failed")
+	total, err := pageCount(input)
+	if err != nil {
+		log.Println("❌ split: cannot read page count:", err)
 		return nil
 	}
 
-	pattern := strings.Replace(outPattern, "%02d", "*", 1)
-	files, _ := filepath.Glob(pattern)
+	if mode == "" || mode == "pages" {
+		base := TempName("split", "")
+
+		if exec.Command("pdfseparate", input, base+"-%d.pdf").Run() != nil {
+			log.Println("❌ pdfseparate missing or failed")
+			return nil
+		}
+
+		// pdfseparate only writes plain %d page numbers; pad them to the
+		// same width so the outputs sort in page order past page 99
+		files, _ := filepath.Glob(base + "-*.pdf")
+		digits := partDigits(total)
+		pages := make([]string, 0, len(files))
+
+		for _, f := range files {
+			n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(f, base+"-"), ".pdf"))
+			if err != nil {
+				continue
+			}
+			padded := fmt.Sprintf("%s-%0*d.pdf", base, digits, n)
+			if err := os.Rename(f, padded); err != nil {
+				log.Println("❌ split: rename failed:", err)
+				leftovers, _ := filepath.Glob(base + "-*.pdf")
+				for _, l := range leftovers {
+					DeleteFile(l)
+				}
+				return nil
+			}
+			pages = append(pages, padded)
+		}
+
+		sort.Strings(pages)
+		return pages
+	}
+
+	var ranges []pageRange
+
+	switch mode {
+
+	case "ranges":
+		ranges, err = parsePageRanges(opts["ranges"], total)
+
+	case "every":
+		n, convErr := strconv.Atoi(opts["every"])
+		if convErr != nil {
+			log.Println("❌ split every: invalid page count:", opts["every"])
+			return nil
+		}
+		ranges, err = splitEvery(n, total)
+
+	case "bookmarks":
+		ranges, err = splitByBookmarks(input, total)
+
+	case "size":
+		mb, convErr := strconv.ParseFloat(opts["maxSizeMB"], 64)
+		if convErr != nil {
+			log.Println("❌ split by size: invalid maxSizeMB:", opts["maxSizeMB"])
+			return nil
+		}
+		files, err := splitBySize(input, int64(mb*1024*1024), total)
+		if err != nil {
+			log.Println("❌ split by size failed:", err)
+			return nil
+		}
+		return files
+
+	default:
+		log.Println("❌ Unknown split mode:", mode)
+		return nil
+	}
+
+	if err != nil {
+		log.Println("❌ split", mode, "failed:", err)
+		return nil
+	}
+
+	files, err := writeSplitParts(input, ranges)
+	if err != nil {
+		log.Println("❌ split", mode, "failed:", err)
+		return nil
+	}
+
+	log.Println("✅ PDF split into", len(files), "files")
 	return files
 }
 
 // ----------------------------
 // COMPRESS PDF
 // ----------------------------
-func compressPDF(input string) string {
-	out := TempName("compressed", ".pdf")
+// opts["profile"]      screen | ebook (default) | printer | prepress
+// opts["dpi"]          image downsampling resolution
+// opts["grayscale"]    "true" to convert to grayscale
+// opts["targetSizeMB"] try profiles until the output fits under it
+func compressPDF(input string, opts map[string]string) (string, *compressReport, error) {
+	settings := compressSettings{
+		Profile:   opts["profile"],
+		Grayscale: opts["grayscale"] == "true",
+	}
+
+	if settings.Profile == "" {
+		settings.Profile = "ebook"
+	}
+	if !isCompressProfile(settings.Profile) {
+		return "", nil, fmt.Errorf("compress: unknown profile %q", settings.Profile)
+	}
+
+	if v := opts["dpi"]; v != "" {
+		dpi, err := strconv.Atoi(v)
+		if err != nil || dpi <= 0 {
+			return "", nil, fmt.Errorf("compress: invalid dpi %q", v)
+		}
+		settings.DPI = dpi
+	}
+
+	report := &compressReport{OriginalSize: fileSize(input)}
+
+	var out string
+
+	if v := opts["targetSizeMB"]; v != "" {
+		mb, err := strconv.ParseFloat(v, 64)
+		if err != nil || mb <= 0 {
+			return "", nil, fmt.Errorf("compress: invalid targetSizeMB %q", v)
+		}
+		report.TargetSize = int64(mb * 1024 * 1024)
+
+		out, settings.Profile, err = compressToTarget(input, report.TargetSize, settings)
+		if err != nil {
+			log.Println("❌ Ghostscript missing or failed:", err)
+			return "", nil, fmt.Errorf("compress: %w", err)
+		}
+	} else {
+		out = TempName("compressed", ".pdf")
+
+		if err := runGhostscriptCompress(input, out, settings); err != nil {
+			DeleteFile(out)
+			log.Println("❌ Ghostscript missing or failed:", err)
+			return "", nil, fmt.Errorf("compress: %w", err)
+		}
+	}
+
+	report.Profile = settings.Profile
+	report.CompressedSize = fileSize(out)
+
+	// Never hand back something bigger than what was uploaded. No
+	// profile was applied then, so it is only reported as tried.
+	if report.CompressedSize >= report.OriginalSize {
+		log.Println("⚠️ Compressed output is not smaller, returning original")
 
-	cmd := exec.Command("gs",
-		"-sDEVICE=pdfwrite",
-		"-dCompatibilityLevel=1.4",
-		"-dPDFSETTINGS=/ebook",
-		"-dNOPAUSE", "-dQUIET", "-dBATCH",
-		"-sOutputFile="+out,
-		input,
-	)
+		if err := copyFile(input, out); err != nil {
+			DeleteFile(out)
+			log.Println("❌ Failed to copy original:", err)
+			return "", nil, fmt.Errorf("compress: %w", err)
+		}
+		report.CompressedSize = report.OriginalSize
+		report.KeptOriginal = true
+		report.TriedProfile = report.Profile
+		report.Profile = ""
+	}
 
-	if cmd.Run() != nil {
-		log.Println("❌ Ghostscript missing or failed")
-		return ""
+	if report.OriginalSize > 0 {
+		report.Ratio = float64(report.CompressedSize) / float64(report.OriginalSize)
+	}
+	if report.TargetSize > 0 {
+		report.TargetMet = report.CompressedSize <= report.TargetSize
 	}
 
-	return out
+	log.Println("✅ PDF compressed:", report.OriginalSize, "→", report.CompressedSize, "bytes")
+	return out, report, nil
 }
 
 // ----------------------------
 // ROTATE PDF
 // ----------------------------
-func rotatePDF(input string, opts map[string]string) string {
-	// ----------------------------
-	// 1. Validate rotation angle
-	// ----------------------------
-	angle := opts["angle"]
-	if angle == "" {
-			angle = "90"
-	}
+// opts["angle"]  rotation for every page (default 90)
+// opts["pages"]  per-page map instead, e.g. "2:90,5-7:180"
+// opts["mode"]   absolute (default, sets the rotation) | relative (adds to it)
+// opts["auto"]   "true" to detect each page's orientation with Tesseract
+//                and fix only the pages that are not upright
 
+func rotatePDF(input string, opts map[string]string) (string, error) {
 	// ----------------------------
-	// 2. Convert to absolute paths (CRITICAL on Linux workers)
+	// 1. Convert to absolute paths (CRITICAL on Linux workers)
 	// ----------------------------
 	absIn, err := filepath.Abs(input)
 	if err != nil {
-			log.Println("❌ Failed to get absolute input path:", err)
-			return ""
+		log.Println("❌ Failed to get absolute input path:", err)
+		return "", err
 	}
 
 	out := TempName("rotated", ".pdf")
 	absOut, err := filepath.Abs(out)
 	if err != nil {
-			log.Println("❌ Failed to get absolute output path:", err)
-			return ""
+		log.Println("❌ Failed to get absolute output path:", err)
+		return "", err
 	}
 
 	// ----------------------------
-	// 3. Validate the PDF before rotating
+	// 2. Validate the PDF before rotating
 	// ----------------------------
-	check := exec.Command("qpdf", "--check", absIn)
-	if err := check.Run(); err != nil {
-			log.Println("❌ Invalid PDF input for rotation:", absIn, "Error:", err)
-			return ""
+	total, err := pageCount(absIn)
+	if err != nil {
+		log.Println("❌ Invalid PDF input for rotation:", absIn, "Error:", err)
+		return "", err
+	}
+
+	// ----------------------------
+	// 3. Work out which pages turn by how much
+	// ----------------------------
+	relative := opts["mode"] == "relative"
+
+	var rotations []pageRotation
+
+	switch {
+	case opts["auto"] == "true":
+		minConfidence := 2.0
+		if v := opts["minConfidence"]; v != "" {
+			if minConfidence, err = strconv.ParseFloat(v, 64); err != nil {
+				return "", fmt.Errorf("rotate: invalid minConfidence %q", v)
+			}
+		}
+
+		rotations, err = detectPageRotations(absIn, total, minConfidence)
+		if err != nil {
+			return "", err
+		}
+		// OSD reports the correction needed, on top of what is shown now
+		relative = true
+
+	case opts["pages"] != "":
+		rotations, err = parseRotationMap(opts["pages"], total)
+		if err != nil {
+			return "", fmt.Errorf("rotate: %w", err)
+		}
+
+	default:
+		angle := opts["angle"]
+		if angle == "" {
+			angle = "90"
+		}
+		a, convErr := strconv.Atoi(strings.TrimPrefix(angle, "+"))
+		if convErr != nil || !validAngle(a) {
+			return "", fmt.Errorf("rotate: invalid angle %q, must be a multiple of 90", angle)
+		}
+		rotations = []pageRotation{{Pages: pageRange{1, total}, Angle: a}}
+	}
+
+	if len(rotations) == 0 {
+		log.Println("✅ All pages already upright, nothing to rotate")
+		return absOut, copyFile(absIn, absOut)
 	}
 
 	// ----------------------------
 	// 4. Perform rotation
 	// ----------------------------
-	cmd := exec.Command("qpdf", "--rotate="+angle, absIn, absOut)
-	outBytes, err := cmd.CombinedOutput()
-	if err != nil {
-			log.Println("❌ qpdf rotation failed")
-			log.Println("Command:", cmd.String())
-			log.Println("Output:", string(outBytes))
-			return ""
+	var args []string
+	for _, r := range rotations {
+		args = append(args, rotationFlag(r, relative))
+	}
+	args = append(args, absIn, absOut)
+
+	if _, err := runQPDF(args...); err != nil {
+		log.Println("❌ qpdf rotation failed")
+		log.Println("Args:", args)
+		return "", err
 	}
 
 	// ----------------------------
 	// 5. Success
 	// ----------------------------
 	log.Println("✅ PDF rotated:", absOut)
-	return absOut
+	return absOut, nil
 }
 
 // ----------------------------
 // REORDER PDF (change page order)
 // ----------------------------
 
-func protectPDF(input string, opts map[string]string) string {
-	password := opts["password"]
-	if password == "" {
-			log.Println("❌ No password provided for protectPDF")
-			return ""
+// opts["userPassword"]  needed to open the file (may be empty)
+// opts["ownerPassword"] needed to change permissions
+// opts["password"]      legacy: used for both when the above are missing
+// opts["encryption"]    aes256 (default) | aes128 | rc4-128 | rc4-40
+// opts["print"]         none | low | full
+// opts["modify"], ["extract"], ["annotate"], ["form"], ["assemble"]: yes/no
+func protectPDF(input string, opts map[string]string) (string, error) {
+	userPW := opts["userPassword"]
+	ownerPW := opts["ownerPassword"]
+
+	if userPW == "" && ownerPW == "" {
+		userPW = opts["password"]
+		ownerPW = opts["password"]
+	}
+	if ownerPW == "" {
+		ownerPW = userPW
+	}
+	if ownerPW == "" {
+		return "", errors.New("protect: no password provided")
+	}
+
+	algorithm := opts["encryption"]
+	if algorithm == "" {
+		algorithm = "aes256"
+	}
+	alg, ok := protectAlgorithms[algorithm]
+	if !ok {
+		return "", fmt.Errorf("protect: unknown encryption %q", algorithm)
+	}
+
+	perms, err := protectPermissionFlags(alg.KeyLength, opts)
+	if err != nil {
+		return "", fmt.Errorf("protect: %w", err)
 	}
 
 	out := TempName("protected", ".pdf")
 
-	cmd := exec.Command("qpdf",
-			"--encrypt", password, password, "256",
-			"--", input, out,
-	)
+	args := []string{"--encrypt", userPW, ownerPW, alg.KeyLength}
+	args = append(args, alg.Flags...)
+	args = append(args, perms...)
+	args = append(args, "--", input, out)
 
-	outBytes, err := cmd.CombinedOutput()
+	if _, err := runQPDF(args...); err != nil {
+		log.Println("❌ qpdf protect failed:", err)
+		return "", err
+	}
+
+	log.Println("✅ PDF protected:", out, "("+algorithm+")")
+	return out, nil
+}
+
+// ----------------------------
+// UNLOCK PDF (remove password)
+// ----------------------------
+var errWrongPassword = errors.New("wrong password")
+
+func unlockPDF(input string, opts map[string]string) (string, error) {
+	out := TempName("unlocked", ".pdf")
+
+	// An empty password still opens files that only have an owner
+	// password; qpdf drops their restrictions on --decrypt.
+	args := []string{"--decrypt"}
+	if password := opts["password"]; password != "" {
+		args = append(args, "--password="+password)
+	}
+	args = append(args, input, out)
+
+	stderr, err := runQPDF(args...)
 	if err != nil {
-			log.Println("❌ qpdf protect failed:", err)
-			log.Println("Output:", string(outBytes))
-			return ""
+		if strings.Contains(strings.ToLower(stderr), "invalid password") {
+			log.Println("❌ unlock: wrong password")
+			return "", errWrongPassword
+		}
+		log.Println("❌ qpdf unlock failed:", err)
+		return "", err
 	}
 
-	log.Println("✅ PDF protected:", out)
-	return out
+	log.Println("✅ PDF unlocked:", out)
+	return out, nil
 }
 
 
@@ -227,8 +553,15 @@ func ProcessJob(job Job) {
 	log.Println("⚙ Processing PDF job:", job.Tool)
 	UpdateStatus(job.ID, "processing")
 
-	// 1. Download all input PDFs
-	var local []string
+	// 1. Download all input PDFs; local copies are removed however the
+	// job ends. names keeps the uploaded file names for titles and reports.
+	var local, names []string
+	defer func() {
+		for _, p := range local {
+			DeleteFile(p)
+		}
+	}()
+
 	for _, f := range job.Files {
 		p := DownloadFromS3(f)
 		if p == "" {
@@ -236,15 +569,27 @@ func ProcessJob(job Job) {
 			return
 		}
 		local = append(local, p)
+		names = append(names, OriginalName(f))
 	}
 
 	var outputs []string
 
+	// optional JSON report saved alongside the result URLs
+	var report interface{}
+
+	// tools that can explain a failure set err instead of returning ""
+	var err error
+
 	// 2. Process
 	switch job.Tool {
 
 	case "merge":
-		out := mergePDFs(local)
+		var out string
+		if job.Options["mode"] == "interleave" {
+			out, err = interleavePDFs(local, job.Options)
+		} else {
+			out, err = mergePDFs(local, names, job.Options)
+		}
 		if out != "" {
 			outputs = []string{out}
 		}
@@ -253,13 +598,16 @@ func ProcessJob(job Job) {
 		outputs = splitPDF(local[0], job.Options)
 
 	case "compress":
-		out := compressPDF(local[0])
+		out, compressed, compressErr := compressPDF(local[0], job.Options)
+		err = compressErr
 		if out != "" {
 			outputs = []string{out}
+			report = compressed
 		}
 
 	case "rotate":
-		out := rotatePDF(local[0], job.Options)
+		var out string
+		out, err = rotatePDF(local[0], job.Options)
 		if out != "" {
 			outputs = []string{out}
 		}
@@ -271,10 +619,189 @@ func ProcessJob(job Job) {
     }
 
 	case "protect":
-    out := protectPDF(local[0], job.Options)
-    if out != "" {
-        outputs = []string{out}
-    }
+		var out string
+		out, err = protectPDF(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "extract-pages":
+		var out string
+		out, err = extractPages(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "delete-pages":
+		var out string
+		out, err = deletePages(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "pdfa":
+		out, conformance, pdfaErr := convertToPDFA(local[0], job.Options)
+		err = pdfaErr
+		if conformance != nil {
+			report = conformance
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "linearize", "repair":
+		var out string
+		var stages *repairReport
+		if job.Tool == "linearize" {
+			out, stages, err = linearizePDF(local[0])
+		} else {
+			out, stages, err = repairPDF(local[0])
+		}
+		if stages != nil {
+			report = stages
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "metadata":
+		out, info, metaErr := metadataPDF(local[0], job.Options)
+		err = metaErr
+		if info != nil {
+			report = info
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "nup":
+		var out string
+		out, err = nupPDF(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "resize", "crop", "add-margin":
+		var out string
+		switch job.Tool {
+		case "resize":
+			out, err = resizePDF(local[0], job.Options)
+		case "crop":
+			out, err = cropPDF(local[0], job.Options)
+		default:
+			out, err = addMarginPDF(local[0], job.Options)
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "form-fields":
+		schema, formErr := formFieldsPDF(local[0])
+		err = formErr
+		if schema != nil {
+			report = schema
+		}
+
+	case "form-fill":
+		var out string
+		out, err = formFillPDF(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "flatten":
+		var out string
+		out, err = flattenPDF(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "redact":
+		out, redactions, redactErr := redactPDF(local[0], job.Options)
+		err = redactErr
+		if redactions != nil {
+			report = redactions
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "compare":
+		outs, diff, compareErr := comparePDFs(local, names, job.Options)
+		err = compareErr
+		if diff != nil {
+			report = diff
+		}
+		outputs = outs
+
+	case "inspect":
+		details, inspectErr := inspectPDF(local[0], job.Options)
+		err = inspectErr
+		if details != nil {
+			report = details
+		}
+
+	case "attach":
+		var out string
+		out, err = attachPDF(local, names, job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "detach":
+		outs, embedded, detachErr := detachPDF(local[0])
+		err = detachErr
+		if embedded != nil {
+			report = embedded
+		}
+		outputs = outs
+
+	case "remove-blank-pages":
+		out, blank, blankErr := removeBlankPages(local[0], job.Options)
+		err = blankErr
+		if blank != nil {
+			report = blank
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "sanitize":
+		out, removed, sanitizeErr := sanitizePDF(local[0], job.Options)
+		err = sanitizeErr
+		if removed != nil {
+			report = removed
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "color-convert":
+		out, converted, colorErr := colorConvertPDF(local, names, job.Options)
+		err = colorErr
+		if converted != nil {
+			report = converted
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "outline":
+		out, tree, outlineErr := outlinePDF(local[0], job.Options)
+		err = outlineErr
+		if tree != nil {
+			report = tree
+		}
+		if out != "" {
+			outputs = []string{out}
+		}
+
+	case "unlock":
+		var out string
+		out, err = unlockPDF(local[0], job.Options)
+		if out != "" {
+			outputs = []string{out}
+		}
 
 	default:
 		log.Println("❌ Unknown tool:", job.Tool)
@@ -283,6 +810,22 @@ func ProcessJob(job Job) {
 	}
 
 	// 3. Validate results
+	if err != nil {
+		// a report can explain what went wrong (e.g. repair stages)
+		if report != nil {
+			SaveReport(job.ID, report)
+		}
+		failJob(job.ID, err)
+		return
+	}
+
+	// read-only tools answer with JSON instead of a file
+	if len(outputs) == 0 && report != nil {
+		SaveJSONResult(job.ID, report)
+		log.Println("✅ PDF job completed (report only):", job.ID)
+		return
+	}
+
 	if len(outputs) == 0 {
 		UpdateStatus(job.ID, "error")
 		log.Println("❌ No output generated")
@@ -301,15 +844,25 @@ func ProcessJob(job Job) {
 		DeleteFile(out)
 	}
 
-	// 5. Cleanup input files
-	for _, p := range local {
-		DeleteFile(p)
+	// 5. Save result
+	if report != nil {
+		SaveReport(job.ID, report)
 	}
-
-	// 6. Save result
 	SaveResult(job.ID, urls)
 
 
 	UpdateStatus(job.ID, "completed")
 	log.Println("✅ PDF job completed:", job.ID)
 }
+
+// Marks the job failed and stores the reason. A wrong password gets its
+// own status so the frontend can ask the user to try again.
+func failJob(jobID string, err error) {
+	status := "error"
+	if errors.Is(err, errWrongPassword) {
+		status = "wrong_password"
+	}
+
+	log.Println("❌ Job failed:", jobID, status, err)
+	SaveError(jobID, status, err.Error())
+}
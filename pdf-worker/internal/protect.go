@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// encryption option → qpdf key length + extra flags
+var protectAlgorithms = map[string]struct {
+	KeyLength string
+	Flags     []string
+}{
+	"aes256":  {"256", nil},
+	"aes128":  {"128", []string{"--use-aes=y"}},
+	"rc4-128": {"128", []string{"--use-aes=n", "--allow-weak-crypto"}},
+	"rc4-40":  {"40", []string{"--allow-weak-crypto"}},
+}
+
+// Options that are plain allow/deny switches, in the order they are
+// passed to qpdf. "modify" maps to --modify-other for 128/256-bit keys.
+var protectSwitches = []string{"modify", "extract", "annotate", "form", "assemble"}
+
+// Accepts true/false, yes/no, y/n, 1/0
+func parseYesNo(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "true", "yes", "y", "1", "allow":
+		return true, nil
+	case "false", "no", "n", "0", "deny":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected yes/no, got %q", v)
+}
+
+func yn(b bool) string {
+	if b {
+		return "y"
+	}
+	return "n"
+}
+
+// ----------------------------
+// QPDF PERMISSION FLAGS
+// ----------------------------
+// Only options that are present restrict anything; missing ones keep
+// qpdf's default of "allowed".
+func protectPermissionFlags(keyLength string, opts map[string]string) ([]string, error) {
+	var flags []string
+
+	if p := strings.ToLower(opts["print"]); p != "" {
+		if p != "none" && p != "low" && p != "full" {
+			return nil, fmt.Errorf("print must be none, low or full, got %q", p)
+		}
+		if keyLength == "40" {
+			// 40-bit keys only know print yes/no
+			flags = append(flags, "--print="+yn(p != "none"))
+		} else {
+			flags = append(flags, "--print="+p)
+		}
+	}
+
+	for _, name := range protectSwitches {
+		v := opts[name]
+		if v == "" {
+			continue
+		}
+
+		allowed, err := parseYesNo(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		if keyLength == "40" {
+			switch name {
+			case "form", "assemble":
+				return nil, fmt.Errorf("%s cannot be restricted with rc4-40 encryption", name)
+			}
+			flags = append(flags, "--"+name+"="+yn(allowed))
+			continue
+		}
+
+		if name == "modify" {
+			name = "modify-other"
+		}
+		flags = append(flags, "--"+name+"="+yn(allowed))
+	}
+
+	return flags, nil
+}
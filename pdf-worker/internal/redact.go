@@ -0,0 +1,697 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Area to remove, in points from the bottom left corner of the page as
+// displayed (the usual PDF convention for unrotated pages)
+type redactRegion struct {
+	Page int     `json:"page"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	W    float64 `json:"w"`
+	H    float64 `json:"h"`
+}
+
+type redactPage struct {
+	Page    int `json:"page"`
+	Regions int `json:"regions"`
+}
+
+// Stored with the result; Scrubbed lists bookmarks and annotation text
+// changed outside the pages, Leftovers whatever the check after
+// redaction could still read
+type redactReport struct {
+	Pages     []redactPage `json:"pages"`
+	Total     int          `json:"total"`
+	Scrubbed  []string     `json:"scrubbed,omitempty"`
+	Verified  bool         `json:"verified"`
+	Leftovers []string     `json:"leftovers,omitempty"`
+}
+
+// ----------------------------
+// TARGETS
+// ----------------------------
+func parseRedactRegions(raw string, pages []layoutPage) ([]redactRegion, error) {
+	var regions []redactRegion
+	if err := json.Unmarshal([]byte(raw), &regions); err != nil {
+		return nil, fmt.Errorf("redact: regions must be a JSON array of {page, x, y, w, h}: %w", err)
+	}
+
+	for i, r := range regions {
+		if r.Page < 1 || r.Page > len(pages) {
+			return nil, fmt.Errorf("redact: region %d: page %d out of range (1-%d)", i+1, r.Page, len(pages))
+		}
+		if r.W <= 0 || r.H <= 0 {
+			return nil, fmt.Errorf("redact: region %d: width and height must be positive", i+1)
+		}
+	}
+
+	return regions, nil
+}
+
+// opts["text"] is matched literally, opts["pattern"] as a Go regexp;
+// opts["ignoreCase"] applies to both
+func redactPattern(opts map[string]string) (*regexp.Regexp, error) {
+	expr := opts["pattern"]
+	if t := opts["text"]; t != "" {
+		if expr != "" {
+			return nil, fmt.Errorf("redact: give either text or pattern, not both")
+		}
+		expr = regexp.QuoteMeta(t)
+	}
+	if expr == "" {
+		return nil, nil
+	}
+
+	if opts["ignoreCase"] != "" {
+		on, err := parseYesNo(opts["ignoreCase"])
+		if err != nil {
+			return nil, fmt.Errorf("redact: ignoreCase: %w", err)
+		}
+		if on {
+			expr = "(?i)" + expr
+		}
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("redact: invalid pattern: %w", err)
+	}
+	return re, nil
+}
+
+// One region per match, covering the words the match touches. Matches
+// are found line by line, so a phrase broken across lines is not found.
+func patternRegions(re *regexp.Regexp, pages []layoutPage) []redactRegion {
+	const pad = 1.0
+	var regions []redactRegion
+
+	for i, p := range pages {
+		for _, l := range p.Lines {
+			s, starts := l.text()
+
+			for _, m := range re.FindAllStringIndex(s, -1) {
+				if m[0] == m[1] {
+					continue
+				}
+
+				x0, y0 := math.Inf(1), math.Inf(1)
+				x1, y1 := math.Inf(-1), math.Inf(-1)
+
+				for j, w := range l.Words {
+					end := starts[j] + len(w.Text)
+					if starts[j] >= m[1] || end <= m[0] {
+						continue
+					}
+					x0, y0 = math.Min(x0, w.XMin), math.Min(y0, w.YMin)
+					x1, y1 = math.Max(x1, w.XMax), math.Max(y1, w.YMax)
+				}
+
+				regions = append(regions, redactRegion{
+					Page: i + 1,
+					X:    x0 - pad,
+					Y:    p.Height - y1 - pad,
+					W:    x1 - x0 + 2*pad,
+					H:    y1 - y0 + 2*pad,
+				})
+			}
+		}
+	}
+
+	return regions
+}
+
+// ----------------------------
+// PAGE REBUILD
+// ----------------------------
+// A redacted page is rendered to an image, the regions are painted black
+// in the pixels, and Tesseract turns the image back into a PDF page with
+// an invisible text layer. None of the original page objects (text,
+// images, fonts, annotations) survive, so nothing under a box can be
+// recovered; the rest of the page stays searchable through OCR.
+func rebuildRedactedPage(input string, page int, pageW, pageH float64, regions []redactRegion, dpi int, lang, workDir string) (string, error) {
+	base := filepath.Join(workDir, "page_"+strconv.Itoa(page))
+	n := strconv.Itoa(page)
+
+	out, err := exec.Command("pdftoppm", "-f", n, "-l", n, "-r", strconv.Itoa(dpi),
+		"-png", "-singlefile", input, base).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("pdftoppm page %d failed: %v: %s", page, err, string(out))
+	}
+
+	f, err := os.Open(base + ".png")
+	if err != nil {
+		return "", err
+	}
+	src, err := png.Decode(f)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	b := src.Bounds()
+	img := image.NewRGBA(b)
+	draw.Draw(img, b, src, b.Min, draw.Src)
+
+	// scale from the rendered size so rounding in pdftoppm can't shift boxes
+	sx := float64(b.Dx()) / pageW
+	sy := float64(b.Dy()) / pageH
+	black := image.NewUniform(color.Black)
+
+	for _, r := range regions {
+		rect := image.Rect(
+			int(math.Floor(r.X*sx)), int(math.Floor((pageH-r.Y-r.H)*sy)),
+			int(math.Ceil((r.X+r.W)*sx)), int(math.Ceil((pageH-r.Y)*sy)),
+		).Add(b.Min).Intersect(b)
+		draw.Draw(img, rect, black, image.Point{}, draw.Src)
+	}
+
+	f, err = os.Create(base + ".png")
+	if err != nil {
+		return "", err
+	}
+	err = png.Encode(f, img)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	out, err = exec.Command("tesseract", base+".png", base+"_ocr",
+		"--dpi", strconv.Itoa(dpi), "-l", lang, "pdf").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("tesseract page %d failed: %v: %s", page, err, string(out))
+	}
+
+	return base + "_ocr.pdf", nil
+}
+
+// qpdf --empty --pages in.pdf 1-2 page_3.pdf 1 in.pdf 4-z -- out.pdf
+// Starting from an empty file means objects only reachable from the old
+// pages (or from outlines pointing at them) are not carried over.
+func assembleRedacted(input string, total int, rebuilt map[int]string, out string) error {
+	args := []string{"--empty", "--pages"}
+
+	from := 1
+	for p := 1; p <= total; p++ {
+		file, ok := rebuilt[p]
+		if !ok {
+			continue
+		}
+		if from < p {
+			args = append(args, input, fmt.Sprintf("%d-%d", from, p-1))
+		}
+		args = append(args, file, "1")
+		from = p + 1
+	}
+	if from <= total {
+		args = append(args, input, fmt.Sprintf("%d-%d", from, total))
+	}
+
+	args = append(args, "--", out)
+
+	_, err := runQPDF(args...)
+	return err
+}
+
+// Bookmarks are lost with --empty; put them back, with matches of the
+// pattern removed from the titles. Returns the titles that changed.
+func restoreOutline(input, out string, re *regexp.Regexp) ([]string, error) {
+	tree, err := exportOutline(input)
+	if err != nil || len(tree.Bookmarks) == 0 {
+		return nil, nil
+	}
+
+	var changed []string
+	if re != nil {
+		var clean func([]outlineItem)
+		clean = func(items []outlineItem) {
+			for i := range items {
+				title := re.ReplaceAllString(items[i].Title, "[REDACTED]")
+				if title != items[i].Title {
+					items[i].Title = title
+					changed = append(changed, fmt.Sprintf("bookmark %q", title))
+				}
+				clean(items[i].Kids)
+			}
+		}
+		clean(tree.Bookmarks)
+	}
+
+	tmp := TempName("redacted_outline", ".pdf")
+	if err := api.AddBookmarksFile(out, tmp, toBookmarks(tree.Bookmarks), true, nil); err != nil {
+		DeleteFile(tmp)
+		return nil, err
+	}
+	return changed, os.Rename(tmp, out)
+}
+
+// ----------------------------
+// TEXT OUTSIDE THE PAGE CONTENT
+// ----------------------------
+// Bookmark titles and annotation text (notes, comments, form values) are
+// not part of the rendered page, and pages without a region keep their
+// annotations. Matches of a text or pattern target are scrubbed from
+// all of them; coordinate targets only blank the annotations and fields
+// lying on a redacted region, since the words under a box say nothing
+// about where else they are sensitive.
+
+// Text entries viewers show for annotations and form fields. A widget's
+// T is the field name and stays; on other annotations T is the author.
+var annotTextKeys = []string{"Contents", "RC", "Subj", "TU", "V", "DV"}
+
+// Calls fn for every text entry of d
+func eachText(ctx *model.Context, d types.Dict, widget bool, fn func(key, text string)) {
+	keys := annotTextKeys
+	if !widget {
+		keys = append(keys[:len(keys):len(keys)], "T")
+	}
+	for _, k := range keys {
+		if d[k] == nil {
+			continue
+		}
+		s, err := ctx.DereferenceStringOrHexLiteral(d[k], model.V10, nil)
+		if err != nil || s == "" {
+			continue // names (checkbox states), streams
+		}
+		fn(k, s)
+	}
+}
+
+func isWidget(d types.Dict) bool {
+	st, ok := d["Subtype"].(types.Name)
+	return ok && st == "Widget"
+}
+
+// Calls fn for every text entry of every annotation and form field
+func eachAnnotationText(ctx *model.Context, fn func(where string, d types.Dict, key, text string)) {
+	visit := func(where string, d types.Dict, widget bool) {
+		eachText(ctx, d, widget, func(key, text string) { fn(where, d, key, text) })
+	}
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		d, _, _, err := ctx.PageDict(p, false)
+		if err != nil || d == nil {
+			continue
+		}
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			continue
+		}
+		for _, o := range annots {
+			ad, err := ctx.DereferenceDict(o)
+			if err != nil || ad == nil {
+				continue
+			}
+			st, _ := ad["Subtype"].(types.Name)
+			visit(fmt.Sprintf("page %d: %s annotation", p, st), ad, st == "Widget")
+		}
+	}
+
+	// field values usually sit on the parent field, not on its widgets
+	form, err := ctx.DereferenceDict(ctx.RootDict["AcroForm"])
+	if err != nil || form == nil {
+		return
+	}
+	var walk func(o types.Object, depth int)
+	walk = func(o types.Object, depth int) {
+		fd, err := ctx.DereferenceDict(o)
+		if err != nil || fd == nil || depth > 32 {
+			return
+		}
+		name, _ := ctx.DereferenceStringOrHexLiteral(fd["T"], model.V10, nil)
+		visit(fmt.Sprintf("form field %q", name), fd, true)
+		kids, _ := ctx.DereferenceArray(fd["Kids"])
+		for _, k := range kids {
+			walk(k, depth+1)
+		}
+	}
+	fields, _ := ctx.DereferenceArray(form["Fields"])
+	for _, f := range fields {
+		walk(f, 0)
+	}
+}
+
+// An annotation (or form field) lying on a redacted region
+type regionAnnot struct {
+	Where  string
+	Dict   types.Dict
+	Widget bool
+}
+
+// Annotations whose Rect overlaps a region of their page, plus the parent
+// fields of such widgets, which hold the field value
+func regionAnnotations(ctx *model.Context, regions []redactRegion) []regionAnnot {
+	var hits []regionAnnot
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		var onPage []redactRegion
+		for _, r := range regions {
+			if r.Page == p {
+				onPage = append(onPage, r)
+			}
+		}
+		if len(onPage) == 0 {
+			continue
+		}
+
+		// regions are given as displayed; Rect is in page space
+		d, box, rot, err := visibleBox(ctx, p)
+		if err != nil {
+			continue
+		}
+		annots, err := ctx.DereferenceArray(d["Annots"])
+		if err != nil {
+			continue
+		}
+
+		for _, o := range annots {
+			ad, err := ctx.DereferenceDict(o)
+			if err != nil || ad == nil {
+				continue
+			}
+			arr, err := ctx.DereferenceArray(ad["Rect"])
+			if err != nil || len(arr) != 4 {
+				continue
+			}
+			rect, err := ctx.RectForArray(arr)
+			if err != nil {
+				continue
+			}
+
+			for _, r := range onPage {
+				pr := displayRectToPage(box, rot, r.X, r.Y, r.X+r.W, r.Y+r.H)
+				if math.Min(rect.UR.X, pr.UR.X) <= math.Max(rect.LL.X, pr.LL.X) ||
+					math.Min(rect.UR.Y, pr.UR.Y) <= math.Max(rect.LL.Y, pr.LL.Y) {
+					continue
+				}
+
+				st, _ := ad["Subtype"].(types.Name)
+				hits = append(hits, regionAnnot{fmt.Sprintf("page %d: %s annotation", p, st), ad, isWidget(ad)})
+				for parent, depth := ad["Parent"], 0; parent != nil && depth < 32; depth++ {
+					pd, err := ctx.DereferenceDict(parent)
+					if err != nil || pd == nil {
+						break
+					}
+					name, _ := ctx.DereferenceStringOrHexLiteral(pd["T"], model.V10, nil)
+					hits = append(hits, regionAnnot{fmt.Sprintf("form field %q", name), pd, true})
+					parent = pd["Parent"]
+				}
+				break
+			}
+		}
+	}
+
+	return hits
+}
+
+// Scrubs annotations and form fields of out: matches of re everywhere,
+// and all text of the ones on a region. A changed annotation loses its
+// appearance stream, which would still show the old text; form fields
+// are regenerated by the viewer (NeedAppearances).
+func scrubAnnotations(out string, re *regexp.Regexp, regions []redactRegion) ([]string, error) {
+	ctx, err := api.ReadContextFile(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	formChanged := false
+
+	set := func(where string, d types.Dict, key, text string) {
+		d[key] = types.NewHexLiteral([]byte(types.EncodeUTF16String(text)))
+		changed = append(changed, where+" /"+key)
+
+		delete(d, "AP")
+		kids, _ := ctx.DereferenceArray(d["Kids"])
+		for _, k := range kids {
+			if kd, err := ctx.DereferenceDict(k); err == nil && kd != nil {
+				delete(kd, "AP")
+			}
+		}
+		if _, ok := d["FT"]; ok || len(kids) > 0 || isWidget(d) {
+			formChanged = true
+		}
+	}
+
+	if re != nil {
+		eachAnnotationText(ctx, func(where string, d types.Dict, key, text string) {
+			if clean := re.ReplaceAllString(text, "[REDACTED]"); clean != text {
+				set(where, d, key, clean)
+			}
+		})
+	}
+
+	for _, h := range regionAnnotations(ctx, regions) {
+		eachText(ctx, h.Dict, h.Widget, func(key, text string) {
+			if text != "[REDACTED]" {
+				set(h.Where, h.Dict, key, "[REDACTED]")
+			}
+		})
+	}
+
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	if formChanged {
+		if form, err := ctx.DereferenceDict(ctx.RootDict["AcroForm"]); err == nil && form != nil {
+			form["NeedAppearances"] = types.Boolean(true)
+		}
+	}
+
+	tmp := TempName("redacted_annots", ".pdf")
+	if err := api.WriteContextFile(ctx, tmp); err != nil {
+		DeleteFile(tmp)
+		return nil, err
+	}
+	return changed, os.Rename(tmp, out)
+}
+
+// ----------------------------
+// VERIFICATION
+// ----------------------------
+// True when most of the word's box lies inside the region
+func wordInRegion(p layoutPage, w layoutWord, r redactRegion) bool {
+	// word box in bottom-left coordinates
+	wx0, wy0 := w.XMin, p.Height-w.YMax
+	wx1, wy1 := w.XMax, p.Height-w.YMin
+
+	ix := math.Min(wx1, r.X+r.W) - math.Max(wx0, r.X)
+	iy := math.Min(wy1, r.Y+r.H) - math.Max(wy0, r.Y)
+	area := (wx1 - wx0) * (wy1 - wy0)
+
+	return ix > 0 && iy > 0 && area > 0 && ix*iy/area > 0.5
+}
+
+// Reads the output again: no pattern match may be left in the page
+// text, bookmarks or annotations, no word may sit inside a redacted
+// region, and no annotation on a region may keep its text.
+func verifyRedaction(out string, re *regexp.Regexp, regions []redactRegion) ([]string, error) {
+	pages, err := textLayout(out)
+	if err != nil {
+		return nil, err
+	}
+
+	var leftovers []string
+
+	for i, p := range pages {
+		if re != nil {
+			for _, l := range p.Lines {
+				s, _ := l.text()
+				for _, m := range re.FindAllString(s, -1) {
+					if m != "" {
+						leftovers = append(leftovers, fmt.Sprintf("page %d: pattern still matches %q", i+1, m))
+					}
+				}
+			}
+		}
+
+		for _, r := range regions {
+			if r.Page != i+1 {
+				continue
+			}
+			for _, l := range p.Lines {
+				for _, w := range l.Words {
+					if wordInRegion(p, w, r) {
+						leftovers = append(leftovers, fmt.Sprintf("page %d: text %q inside a redacted region", i+1, w.Text))
+					}
+				}
+			}
+		}
+	}
+
+	ctx, err := api.ReadContextFile(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if re != nil {
+		if tree, err := exportOutline(out); err == nil {
+			var check func([]outlineItem)
+			check = func(items []outlineItem) {
+				for _, it := range items {
+					if re.MatchString(it.Title) {
+						leftovers = append(leftovers, fmt.Sprintf("bookmark %q still matches the pattern", it.Title))
+					}
+					check(it.Kids)
+				}
+			}
+			check(tree.Bookmarks)
+		}
+
+		eachAnnotationText(ctx, func(where string, _ types.Dict, key, text string) {
+			if re.MatchString(text) {
+				leftovers = append(leftovers, fmt.Sprintf("%s /%s still matches the pattern", where, key))
+			}
+		})
+	}
+
+	for _, h := range regionAnnotations(ctx, regions) {
+		eachText(ctx, h.Dict, h.Widget, func(key, text string) {
+			if text != "[REDACTED]" {
+				leftovers = append(leftovers, fmt.Sprintf("%s /%s inside a redacted region still has text", h.Where, key))
+			}
+		})
+	}
+
+	return leftovers, nil
+}
+
+// ----------------------------
+// REDACT TOOL
+// ----------------------------
+// opts["regions"]    JSON array: [{"page": 1, "x": 72, "y": 650, "w": 200, "h": 14}]
+// opts["text"]       literal text to remove, or
+// opts["pattern"]    regular expression (e.g. \d{3}-\d{2}-\d{4})
+// opts["ignoreCase"] yes | no
+// opts["dpi"]        resolution of rebuilt pages, default 300
+// opts["lang"]       Tesseract language(s) for the text layer, default eng
+//
+// Pages without anything to redact are copied unchanged, except that
+// pattern matches are scrubbed from bookmarks and annotations everywhere.
+func redactPDF(input string, opts map[string]string) (string, *redactReport, error) {
+	layout, err := textLayout(input)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var regions []redactRegion
+	if raw := strings.TrimSpace(opts["regions"]); raw != "" {
+		if regions, err = parseRedactRegions(raw, layout); err != nil {
+			return "", nil, err
+		}
+	}
+
+	re, err := redactPattern(opts)
+	if err != nil {
+		return "", nil, err
+	}
+	if re != nil {
+		regions = append(regions, patternRegions(re, layout)...)
+	}
+
+	if len(regions) == 0 {
+		if re == nil {
+			return "", nil, fmt.Errorf("redact: give regions, text or pattern")
+		}
+		return "", nil, fmt.Errorf("redact: %q not found in the document text", re.String())
+	}
+
+	dpi := 300
+	if v := opts["dpi"]; v != "" {
+		if dpi, err = strconv.Atoi(v); err != nil || dpi < 72 || dpi > 600 {
+			return "", nil, fmt.Errorf("redact: dpi must be between 72 and 600")
+		}
+	}
+	lang := opts["lang"]
+	if lang == "" {
+		lang = "eng"
+	}
+
+	byPage := map[int][]redactRegion{}
+	for _, r := range regions {
+		byPage[r.Page] = append(byPage[r.Page], r)
+	}
+
+	report := &redactReport{Total: len(regions)}
+	for p, rs := range byPage {
+		report.Pages = append(report.Pages, redactPage{Page: p, Regions: len(rs)})
+	}
+	sort.Slice(report.Pages, func(i, j int) bool { return report.Pages[i].Page < report.Pages[j].Page })
+
+	workDir, err := os.MkdirTemp("/tmp", "redact_")
+	if err != nil {
+		return "", nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	rebuilt := map[int]string{}
+	for _, rp := range report.Pages {
+		lp := layout[rp.Page-1]
+		file, err := rebuildRedactedPage(input, rp.Page, lp.Width, lp.Height, byPage[rp.Page], dpi, lang, workDir)
+		if err != nil {
+			log.Println("❌ Redaction failed:", err)
+			return "", report, err
+		}
+		rebuilt[rp.Page] = file
+	}
+
+	out := TempName("redacted", ".pdf")
+	if err := assembleRedacted(input, len(layout), rebuilt, out); err != nil {
+		log.Println("❌ qpdf assemble failed:", err)
+		return "", report, err
+	}
+
+	titles, err := restoreOutline(input, out, re)
+	if err != nil {
+		log.Println("⚠️ Bookmarks not restored:", err)
+	}
+	report.Scrubbed = titles
+
+	annots, err := scrubAnnotations(out, re, regions)
+	if err != nil {
+		DeleteFile(out)
+		log.Println("❌ Scrubbing annotations failed:", err)
+		return "", report, fmt.Errorf("redact: %w", err)
+	}
+	report.Scrubbed = append(report.Scrubbed, annots...)
+
+	leftovers, err := verifyRedaction(out, re, regions)
+	if err != nil {
+		DeleteFile(out)
+		return "", report, fmt.Errorf("redact: verification failed: %w", err)
+	}
+
+	report.Leftovers = leftovers
+	report.Verified = len(leftovers) == 0
+
+	// never hand out a file that still leaks what was asked to be removed
+	if !report.Verified {
+		DeleteFile(out)
+		log.Println("❌ Redaction verification found", len(leftovers), "leftovers")
+		return "", report, fmt.Errorf("redact: redacted content is still readable (%d leftovers)", len(leftovers))
+	}
+
+	log.Println("✅ PDF redacted:", report.Total, "regions on", len(report.Pages), "pages →", out)
+	return out, report, nil
+}
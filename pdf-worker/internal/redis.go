@@ -39,3 +39,33 @@ func SaveResult(jobID string, urls []string) {
 	client.Set(ctx, "result:"+jobID, string(b), 0)
 	client.Set(ctx, "job:"+jobID, "completed", 0)
 }
+
+// Tools that measure or inspect something store a JSON report next to
+// the result: report:<id>
+func SaveReport(jobID string, report interface{}) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		log.Println("❌ Report encode error:", err)
+		return
+	}
+	client.Set(ctx, "report:"+jobID, string(b), 0)
+}
+
+// Failed jobs keep a readable reason in error:<id>
+func SaveError(jobID, status, message string) {
+	client.Set(ctx, "error:"+jobID, message, 0)
+	UpdateStatus(jobID, status)
+}
+
+// Read-only tools (e.g. metadata read, outline export) answer with JSON in
+// result:<id> instead of an array of file URLs
+func SaveJSONResult(jobID string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Println("❌ Result encode error:", err)
+		UpdateStatus(jobID, "error")
+		return
+	}
+	client.Set(ctx, "result:"+jobID, string(b), 0)
+	client.Set(ctx, "job:"+jobID, "completed", 0)
+}
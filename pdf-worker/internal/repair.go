@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// What one tool did while processing the file
+type repairStage struct {
+	Tool     string   `json:"tool"`
+	Success  bool     `json:"success"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Stored with the result so support can see what was fixed
+type repairReport struct {
+	FixedBy string        `json:"fixedBy,omitempty"`
+	Stages  []repairStage `json:"stages"`
+}
+
+func splitLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// ----------------------------
+// LINEARIZE (fast web view)
+// ----------------------------
+func linearizePDF(input string) (string, *repairReport, error) {
+	out := TempName("linearized", ".pdf")
+
+	warnings, err := runQPDF("--linearize", input, out)
+
+	stage := repairStage{Tool: "qpdf --linearize", Success: err == nil, Warnings: splitLines(warnings)}
+	report := &repairReport{Stages: []repairStage{stage}}
+
+	if err != nil {
+		log.Println("❌ qpdf linearize failed:", err)
+		return "", report, err
+	}
+
+	report.FixedBy = stage.Tool
+	log.Println("✅ PDF linearized:", out)
+	return out, report, nil
+}
+
+// ----------------------------
+// REPAIR
+// ----------------------------
+// qpdf rebuilds broken xref tables and streams on a plain rewrite. If it
+// gives up, Ghostscript re-distills the whole document from what it can
+// still render.
+func repairPDF(input string) (string, *repairReport, error) {
+	report := &repairReport{}
+
+	// Stage 1: qpdf
+	out := TempName("repaired", ".pdf")
+
+	warnings, err := runQPDF(input, out)
+	stage := repairStage{Tool: "qpdf", Success: err == nil, Warnings: splitLines(warnings)}
+	if err != nil {
+		stage.Warnings = nil
+		stage.Error = err.Error()
+	}
+	report.Stages = append(report.Stages, stage)
+
+	if err == nil {
+		report.FixedBy = "qpdf"
+		log.Println("✅ PDF repaired with qpdf:", out)
+		return out, report, nil
+	}
+
+	log.Println("⚠️ qpdf repair failed, trying Ghostscript:", err)
+	DeleteFile(out)
+
+	// Stage 2: Ghostscript
+	out = TempName("repaired_gs", ".pdf")
+
+	gsOut, err := exec.Command("gs",
+		"-sDEVICE=pdfwrite",
+		"-dNOPAUSE", "-dBATCH", "-dSAFER",
+		"-sOutputFile="+out,
+		input,
+	).CombinedOutput()
+
+	stage = repairStage{Tool: "ghostscript", Success: err == nil}
+	for _, l := range splitLines(string(gsOut)) {
+		// gs reports recoverable problems as "**** Error/Warning: ..."
+		if strings.HasPrefix(l, "****") {
+			stage.Warnings = append(stage.Warnings, strings.TrimSpace(strings.TrimLeft(l, "* ")))
+		}
+	}
+	if err != nil {
+		stage.Error = fmt.Sprintf("%v", err)
+	}
+	report.Stages = append(report.Stages, stage)
+
+	if err != nil {
+		log.Println("❌ Ghostscript repair failed:", err)
+		DeleteFile(out)
+		return "", report, fmt.Errorf("repair: file could not be recovered")
+	}
+
+	report.FixedBy = "ghostscript"
+	log.Println("✅ PDF repaired with Ghostscript:", out)
+	return out, report, nil
+}
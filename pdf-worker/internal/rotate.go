@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// one qpdf --rotate instruction
+type pageRotation struct {
+	Pages pageRange
+	Angle int
+}
+
+func validAngle(a int) bool {
+	return a%90 == 0 && a >= -270 && a <= 270
+}
+
+// ----------------------------
+// PAGE → ANGLE MAP
+// ----------------------------
+// Parses "2:90,5-7:180" against a document of `total` pages.
+func parseRotationMap(expr string, total int) ([]pageRotation, error) {
+	var rotations []pageRotation
+
+	for _, item := range strings.Split(strings.ReplaceAll(expr, " ", ""), ",") {
+		if item == "" {
+			continue
+		}
+
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rotation %q, expected page:angle", item)
+		}
+
+		ranges, err := parsePageRanges(parts[0], total)
+		if err != nil {
+			return nil, err
+		}
+
+		angle, err := strconv.Atoi(strings.TrimPrefix(parts[1], "+"))
+		if err != nil || !validAngle(angle) {
+			return nil, fmt.Errorf("invalid angle %q, must be a multiple of 90", parts[1])
+		}
+
+		rotations = append(rotations, pageRotation{Pages: ranges[0], Angle: angle})
+	}
+
+	if len(rotations) == 0 {
+		return nil, fmt.Errorf("empty rotation map")
+	}
+
+	return rotations, nil
+}
+
+// qpdf sets the angle when it has no sign and adds it when it has one
+func rotationFlag(r pageRotation, relative bool) string {
+	angle := strconv.Itoa(r.Angle)
+	if relative && r.Angle >= 0 {
+		angle = "+" + angle
+	}
+	if !relative && r.Angle < 0 {
+		angle = strconv.Itoa(r.Angle + 360)
+	}
+	return "--rotate=" + angle + ":" + r.Pages.String()
+}
+
+// ----------------------------
+// AUTO ORIENTATION (Tesseract OSD)
+// ----------------------------
+var osdRotateRe = regexp.MustCompile(`Rotate:\s*(\d+)`)
+var osdConfidenceRe = regexp.MustCompile(`Orientation confidence:\s*([\d.]+)`)
+
+// Renders every page and asks Tesseract which way is up. Pages that are
+// already upright, blank, or detected with low confidence are left out.
+func detectPageRotations(input string, total int, minConfidence float64) ([]pageRotation, error) {
+	workDir, err := os.MkdirTemp("/tmp", "osd_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	var rotations []pageRotation
+
+	for p := 1; p <= total; p++ {
+		base := filepath.Join(workDir, "page_"+strconv.Itoa(p))
+		n := strconv.Itoa(p)
+
+		out, err := exec.Command("pdftoppm", "-f", n, "-l", n, "-r", "150",
+			"-gray", "-png", "-singlefile", input, base).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("pdftoppm page %d failed: %v: %s", p, err, string(out))
+		}
+
+		osd, err := exec.Command("tesseract", base+".png", "-", "--psm", "0").CombinedOutput()
+		if err != nil {
+			// usually "Too few characters" on blank or image-only pages
+			log.Println("⚠️ OSD skipped page", p, ":", strings.TrimSpace(string(osd)))
+			continue
+		}
+
+		rot := osdRotateRe.FindStringSubmatch(string(osd))
+		conf := osdConfidenceRe.FindStringSubmatch(string(osd))
+		if rot == nil || conf == nil {
+			continue
+		}
+
+		angle, _ := strconv.Atoi(rot[1])
+		confidence, _ := strconv.ParseFloat(conf[1], 64)
+
+		if angle == 0 || confidence < minConfidence {
+			continue
+		}
+
+		log.Println("🧭 Page", p, "needs", angle, "° (confidence", confidence, ")")
+		rotations = append(rotations, pageRotation{Pages: pageRange{p, p}, Angle: angle})
+	}
+
+	return rotations, nil
+}
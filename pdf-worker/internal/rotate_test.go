@@ -0,0 +1,26 @@
+package internal
+
+import "testing"
+
+func TestRotationFlag(t *testing.T) {
+	tests := []struct {
+		rotation pageRotation
+		relative bool
+		want     string
+	}{
+		{pageRotation{pageRange{1, 1}, 90}, true, "--rotate=+90:1"},
+		{pageRotation{pageRange{2, 4}, 0}, true, "--rotate=+0:2-4"},
+		{pageRotation{pageRange{3, 3}, -90}, true, "--rotate=-90:3"},
+		{pageRotation{pageRange{1, 5}, 180}, false, "--rotate=180:1-5"},
+		{pageRotation{pageRange{2, 2}, 0}, false, "--rotate=0:2"},
+		{pageRotation{pageRange{2, 2}, -90}, false, "--rotate=270:2"},
+		{pageRotation{pageRange{6, 6}, -270}, false, "--rotate=90:6"},
+		{pageRotation{pageRange{7, 5}, 90}, true, "--rotate=+90:7-5"},
+	}
+
+	for _, tt := range tests {
+		if got := rotationFlag(tt.rotation, tt.relative); got != tt.want {
+			t.Errorf("rotationFlag(%v, %v) = %q, want %q", tt.rotation, tt.relative, got, tt.want)
+		}
+	}
+}
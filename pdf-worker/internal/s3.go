@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"log"
+	"mime"
 	"os"
 	"path/filepath"
 	"strings"
@@ -56,13 +57,23 @@ func ExtractS3Key(fileURL string) string {
 	return ""
 }
 
+// File name of an upload as the user knows it (local copies get a
+// unique temp name)
+func OriginalName(url string) string {
+	return filepath.Base(ExtractS3Key(url))
+}
+
 func DownloadFromS3(url string) string {
 	key := ExtractS3Key(url)
 	if key == "" {
 		return ""
 	}
 
-	localPath := filepath.Join("/tmp", filepath.Base(key))
+	// unique local name: a job may contain several files with the same
+	// base name (v1/contract.pdf, v2/contract.pdf)
+	base := filepath.Base(key)
+	ext := filepath.Ext(base)
+	localPath := TempName(strings.TrimSuffix(base, ext), ext)
 
 	out, err := s3Client.GetObject(context.TODO(), &s3.GetObjectInput{
 		Bucket: &bucket,
@@ -89,10 +100,17 @@ func UploadToS3(path string) string {
 	f, _ := os.Open(path)
 	defer f.Close()
 
+	// reports (.html, .json) should open in the browser, not download
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
 	_, err := s3Client.PutObject(context.TODO(), &s3.PutObjectInput{
-		Bucket: &bucket,
-		Key:    &key,
-		Body:   f,
+		Bucket:      &bucket,
+		Key:         &key,
+		Body:        f,
+		ContentType: &contentType,
 	})
 
 	if err != nil {
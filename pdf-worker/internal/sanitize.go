@@ -0,0 +1,436 @@
+package internal
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// One thing taken out of the file
+type sanitizeItem struct {
+	Element  string `json:"element"`
+	Location string `json:"location"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type sanitizeReport struct {
+	NormalizedBy string         `json:"normalizedBy"`
+	Removed      []sanitizeItem `json:"removed"`
+}
+
+// Actions that run code or touch the outside world. The second group
+// only goes when external links are stripped too.
+var riskyActions = map[string]string{
+	"JavaScript":       "JavaScript action",
+	"Launch":           "Launch action",
+	"ImportData":       "ImportData action",
+	"Rendition":        "Rendition action",
+	"RichMediaExecute": "RichMediaExecute action",
+}
+
+var externalActions = map[string]string{
+	"URI":        "external link (URI)",
+	"GoToR":      "link to another file (GoToR)",
+	"GoToE":      "link to an embedded file (GoToE)",
+	"SubmitForm": "form submission (SubmitForm)",
+}
+
+type sanitizer struct {
+	ctx      *model.Context
+	links    bool
+	metadata bool
+	pages    map[int]int // page object number → page number
+	visited  map[int]bool
+	report   *sanitizeReport
+}
+
+func (s *sanitizer) remove(element, location, detail string) {
+	s.report.Removed = append(s.report.Removed, sanitizeItem{element, location, detail})
+}
+
+func (s *sanitizer) text(o types.Object) string {
+	v, err := s.ctx.DereferenceStringOrHexLiteral(o, model.V10, nil)
+	if err != nil {
+		return ""
+	}
+	if r := []rune(v); len(r) > 80 {
+		v = string(r[:80]) + "…"
+	}
+	return v
+}
+
+// Returns what the action is when it has to go, "" when it may stay
+func (s *sanitizer) riskyAction(o types.Object) (string, string) {
+	d, err := s.ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return "", "" // OpenAction may be a plain destination array
+	}
+
+	kind, _ := d["S"].(types.Name)
+	if _, ok := d["JS"]; ok {
+		kind = "JavaScript"
+	}
+
+	if element, ok := riskyActions[string(kind)]; ok {
+		return element, s.actionDetail(d)
+	}
+	if element, ok := externalActions[string(kind)]; ok && s.links {
+		return element, s.actionDetail(d)
+	}
+	return "", ""
+}
+
+// The script, URL or file an action points at
+func (s *sanitizer) actionDetail(d types.Dict) string {
+	for _, key := range []string{"JS", "URI", "F"} {
+		if fs, err := s.ctx.DereferenceDict(d[key]); err == nil && fs != nil {
+			return s.text(fs["F"])
+		}
+		if v := s.text(d[key]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// A harmless action can chain others through Next (a dict or an array)
+func (s *sanitizer) pruneNext(o types.Object, where string) {
+	d, err := s.ctx.DereferenceDict(o)
+	if err != nil || d == nil {
+		return
+	}
+
+	switch next := d["Next"].(type) {
+	case nil:
+		return
+	case types.Array:
+		var kept types.Array
+		for _, n := range next {
+			if element, detail := s.riskyAction(n); element != "" {
+				s.remove(element, where, detail)
+				continue
+			}
+			s.pruneNext(n, where)
+			kept = append(kept, n)
+		}
+		if len(kept) == 0 {
+			delete(d, "Next")
+		} else {
+			d["Next"] = kept
+		}
+	default:
+		if element, detail := s.riskyAction(next); element != "" {
+			s.remove(element, where, detail)
+			delete(d, "Next")
+			return
+		}
+		s.pruneNext(next, where)
+	}
+}
+
+// Names the place a dictionary belongs to, for the report
+func (s *sanitizer) label(d types.Dict, where string) string {
+	if _, ok := d["FT"]; ok {
+		return fmt.Sprintf("form field %q", s.text(d["T"]))
+	}
+	if st, ok := d["Subtype"].(types.Name); ok {
+		if _, ok := d["Rect"]; ok {
+			return where + ", " + string(st) + " annotation"
+		}
+	}
+	if _, ok := d["Title"]; ok {
+		return fmt.Sprintf("bookmark %q", s.text(d["Title"]))
+	}
+	return where
+}
+
+func sortedKeys(d types.Dict) []string {
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Page annotations that carry a whole file with them
+func (s *sanitizer) dropFileAttachments(page types.Dict, where string) {
+	annots, err := s.ctx.DereferenceArray(page["Annots"])
+	if err != nil || annots == nil {
+		return
+	}
+
+	var kept types.Array
+	for _, o := range annots {
+		ad, err := s.ctx.DereferenceDict(o)
+		if err == nil && ad != nil {
+			if st, ok := ad["Subtype"].(types.Name); ok && st == "FileAttachment" {
+				detail := ""
+				if fs, err := s.ctx.DereferenceDict(ad["FS"]); err == nil && fs != nil {
+					detail = s.text(fs["UF"])
+					if detail == "" {
+						detail = s.text(fs["F"])
+					}
+				}
+				s.remove("embedded file (FileAttachment annotation)", where, detail)
+				continue
+			}
+		}
+		kept = append(kept, o)
+	}
+
+	if len(kept) == 0 {
+		delete(page, "Annots")
+	} else if len(kept) != len(annots) {
+		page["Annots"] = kept
+	}
+}
+
+func (s *sanitizer) walk(o types.Object, where string, depth int) {
+	if depth > 200 {
+		return
+	}
+
+	switch t := o.(type) {
+	case types.IndirectRef:
+		nr := t.ObjectNumber.Value()
+		if s.visited[nr] {
+			return
+		}
+		s.visited[nr] = true
+		if p, ok := s.pages[nr]; ok {
+			where = fmt.Sprintf("page %d", p)
+		}
+		obj, err := s.ctx.Dereference(t)
+		if err != nil {
+			return
+		}
+		s.walk(obj, where, depth+1)
+
+	case types.Dict:
+		s.dict(t, where, depth)
+
+	case types.StreamDict:
+		s.dict(t.Dict, where, depth)
+
+	case types.Array:
+		for _, v := range t {
+			s.walk(v, where, depth+1)
+		}
+	}
+}
+
+func (s *sanitizer) dict(d types.Dict, where string, depth int) {
+	where = s.label(d, where)
+
+	if tp, ok := d["Type"].(types.Name); ok && tp == "Page" {
+		s.dropFileAttachments(d, where)
+	}
+
+	for _, key := range []string{"A", "OpenAction"} {
+		if _, ok := d[key]; !ok {
+			continue
+		}
+		if element, detail := s.riskyAction(d[key]); element != "" {
+			if key == "OpenAction" {
+				element += " run on open"
+			}
+			s.remove(element, where, detail)
+			delete(d, key)
+			continue
+		}
+		s.pruneNext(d[key], where)
+	}
+
+	// additional actions fire on events: page open, field change, ...
+	if aa, err := s.ctx.DereferenceDict(d["AA"]); err == nil && aa != nil {
+		for _, event := range sortedKeys(aa) {
+			if element, detail := s.riskyAction(aa[event]); element != "" {
+				s.remove(element+" on event "+event, where, detail)
+				delete(aa, event)
+				continue
+			}
+			s.pruneNext(aa[event], where)
+		}
+		if len(aa) == 0 {
+			delete(d, "AA")
+		}
+	}
+
+	if s.metadata {
+		if _, ok := d["Metadata"]; ok {
+			s.remove("XMP metadata", where, "")
+			delete(d, "Metadata")
+		}
+		if _, ok := d["PieceInfo"]; ok {
+			s.remove("application data (PieceInfo)", where, "")
+			delete(d, "PieceInfo")
+		}
+	}
+
+	for _, k := range sortedKeys(d) {
+		switch k {
+		case "Parent", "P", "A", "AA", "OpenAction":
+			// back references and actions already handled
+			continue
+		}
+		s.walk(d[k], where, depth+1)
+	}
+}
+
+// Leaf keys of a name tree
+func nameTreeKeys(ctx *model.Context, o types.Object, depth int) []string {
+	d, err := ctx.DereferenceDict(o)
+	if err != nil || d == nil || depth > 32 {
+		return nil
+	}
+
+	var keys []string
+	if names, err := ctx.DereferenceArray(d["Names"]); err == nil {
+		for i := 0; i+1 < len(names); i += 2 {
+			if k, err := ctx.DereferenceStringOrHexLiteral(names[i], model.V10, nil); err == nil {
+				keys = append(keys, k)
+			}
+		}
+	}
+	if kids, err := ctx.DereferenceArray(d["Kids"]); err == nil {
+		for _, k := range kids {
+			keys = append(keys, nameTreeKeys(ctx, k, depth+1)...)
+		}
+	}
+	return keys
+}
+
+// Catalog level content: document scripts, attachments, XFA
+func (s *sanitizer) catalog() {
+	root := s.ctx.RootDict
+
+	if names, err := s.ctx.DereferenceDict(root["Names"]); err == nil && names != nil {
+		if _, ok := names["JavaScript"]; ok {
+			for _, k := range nameTreeKeys(s.ctx, names["JavaScript"], 0) {
+				s.remove("document JavaScript", "document", k)
+			}
+			delete(names, "JavaScript")
+			delete(s.ctx.Names, "JavaScript")
+		}
+		if _, ok := names["EmbeddedFiles"]; ok {
+			for _, k := range nameTreeKeys(s.ctx, names["EmbeddedFiles"], 0) {
+				s.remove("embedded file", "document", k)
+			}
+			delete(names, "EmbeddedFiles")
+			delete(s.ctx.Names, "EmbeddedFiles")
+		}
+		if len(names) == 0 {
+			delete(root, "Names")
+		}
+	}
+
+	if _, ok := root["AF"]; ok {
+		s.remove("associated files (AF)", "document", "")
+		delete(root, "AF")
+	}
+
+	if form, err := s.ctx.DereferenceDict(root["AcroForm"]); err == nil && form != nil {
+		if _, ok := form["XFA"]; ok {
+			s.remove("XFA form", "document", "")
+			delete(form, "XFA")
+		}
+	}
+	delete(root, "NeedsRendering")
+
+	if s.metadata && s.ctx.Info != nil {
+		if info, err := s.ctx.DereferenceDict(*s.ctx.Info); err == nil && len(info) > 0 {
+			s.remove("document info", "document", strings.Join(sortedKeys(info), ", "))
+		}
+	}
+}
+
+// ----------------------------
+// SANITIZE
+// ----------------------------
+// The file is first rewritten by the repair tool, which normalizes
+// malformed objects. Everything removed here is only unlinked; the
+// final qpdf rewrite is what drops the objects from the file.
+//
+// opts["links"]    yes: also strip external links and form submission
+// opts["metadata"] no: keep document info and XMP metadata (default yes)
+func sanitizePDF(input string, opts map[string]string) (string, *sanitizeReport, error) {
+	links, metadata := false, true
+	var err error
+	if v := opts["links"]; v != "" {
+		if links, err = parseYesNo(v); err != nil {
+			return "", nil, fmt.Errorf("sanitize: links: %w", err)
+		}
+	}
+	if v := opts["metadata"]; v != "" {
+		if metadata, err = parseYesNo(v); err != nil {
+			return "", nil, fmt.Errorf("sanitize: metadata: %w", err)
+		}
+	}
+
+	fixed, repair, err := repairPDF(input)
+	if err != nil {
+		return "", nil, fmt.Errorf("sanitize: could not normalize the file: %w", err)
+	}
+	defer DeleteFile(fixed)
+
+	ctx, err := api.ReadContextFile(fixed)
+	if err != nil {
+		return "", nil, fmt.Errorf("sanitize: %w", err)
+	}
+
+	s := &sanitizer{
+		ctx:      ctx,
+		links:    links,
+		metadata: metadata,
+		pages:    map[int]int{},
+		visited:  map[int]bool{},
+		report:   &sanitizeReport{NormalizedBy: repair.FixedBy, Removed: []sanitizeItem{}},
+	}
+
+	for p := 1; p <= ctx.PageCount; p++ {
+		if _, ir, _, err := ctx.PageDict(p, false); err == nil && ir != nil {
+			s.pages[ir.ObjectNumber.Value()] = p
+		}
+	}
+
+	s.catalog()
+	s.dict(ctx.RootDict, "document", 0)
+
+	tmp := TempName("sanitize_tmp", ".pdf")
+	defer DeleteFile(tmp)
+	if err := api.WriteContextFile(ctx, tmp); err != nil {
+		return "", s.report, fmt.Errorf("sanitize: %w", err)
+	}
+
+	out := TempName("sanitized", ".pdf")
+	if metadata {
+		err = runExiftoolAndRewrite(tmp, out, []string{"-all:all="})
+	} else {
+		_, err = runQPDF(tmp, out)
+	}
+	if err != nil {
+		DeleteFile(out)
+		log.Println("❌ Sanitize rewrite failed:", err)
+		return "", s.report, err
+	}
+
+	// nothing unlinked may survive the rewrite
+	check, err := api.ReadContextFile(out)
+	if err == nil && hasJavaScript(check) {
+		err = fmt.Errorf("JavaScript still present after rewrite")
+	}
+	if err != nil {
+		DeleteFile(out)
+		log.Println("❌ Sanitize verification failed:", err)
+		return "", s.report, fmt.Errorf("sanitize: %w", err)
+	}
+
+	log.Println("✅ PDF sanitized:", len(s.report.Removed), "elements removed →", out)
+	return out, s.report, nil
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// ----------------------------
+// SPLIT OUTPUT WRITER
+// ----------------------------
+// Writes one file per range, named <base>_<NN>_pages_<from>-<to>.pdf so
+// the outputs sort in page order.
+func writeSplitParts(input string, ranges []pageRange) ([]string, error) {
+	base := TempName("split", "")
+	digits := partDigits(len(ranges))
+
+	var outputs []string
+	for i, r := range ranges {
+		out := fmt.Sprintf("%s_%0*d_pages_%d-%d.pdf", base, digits, i+1, r.From, r.To)
+
+		if err := writePages(input, out, []pageRange{r}); err != nil {
+			for _, o := range outputs {
+				DeleteFile(o)
+			}
+			return nil, err
+		}
+
+		outputs = append(outputs, out)
+	}
+
+	return outputs, nil
+}
+
+// Width of the part number in output names: at least 2, and enough for
+// the last part so names sort numerically (part 100 after part 099).
+func partDigits(parts int) int {
+	if n := len(strconv.Itoa(parts)); n > 2 {
+		return n
+	}
+	return 2
+}
+
+// ----------------------------
+// MODE: every N pages
+// ----------------------------
+func splitEvery(n, total int) ([]pageRange, error) {
+	if n < 1 {
+		return nil, errors.New("split every: page count must be at least 1")
+	}
+
+	var ranges []pageRange
+	for from := 1; from <= total; from += n {
+		to := from + n - 1
+		if to > total {
+			to = total
+		}
+		ranges = append(ranges, pageRange{From: from, To: to})
+	}
+	return ranges, nil
+}
+
+// ----------------------------
+// MODE: top-level bookmarks
+// ----------------------------
+// Each top-level bookmark starts a new part. Pages before the first
+// bookmark (cover, TOC...) become their own part.
+func splitByBookmarks(input string, total int) ([]pageRange, error) {
+	f, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bms, err := api.Bookmarks(f, nil)
+	if err != nil {
+		return nil, fmt.Errorf("reading bookmarks: %w", err)
+	}
+
+	if len(bms) == 0 {
+		return nil, errors.New("document has no bookmarks")
+	}
+
+	seen := map[int]bool{1: true}
+	starts := []int{1}
+	for _, bm := range bms {
+		if bm.PageFrom >= 1 && bm.PageFrom <= total && !seen[bm.PageFrom] {
+			seen[bm.PageFrom] = true
+			starts = append(starts, bm.PageFrom)
+		}
+	}
+
+	sort.Ints(starts)
+
+	var ranges []pageRange
+	for i, from := range starts {
+		to := total
+		if i+1 < len(starts) {
+			to = starts[i+1] - 1
+		}
+		ranges = append(ranges, pageRange{From: from, To: to})
+	}
+	return ranges, nil
+}
+
+// ----------------------------
+// MODE: maximum file size
+// ----------------------------
+// Pages are grouped greedily using the size of each page written on its
+// own. Fonts and images shared between pages are counted once per page,
+// so the estimate is usually too high and each group is written just
+// once to confirm it. When a group is still too big, the longest prefix
+// that fits is found by bisection, so a part costs O(log n) writes.
+// A single page larger than the limit is emitted alone.
+func splitBySize(input string, maxBytes int64, total int) ([]string, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("split by size: maximum size must be positive")
+	}
+
+	workDir, err := os.MkdirTemp("/tmp", "split_size_")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	// 1. Measure every page on its own
+	pageSizes := make([]int64, total+1)
+	for p := 1; p <= total; p++ {
+		single := filepath.Join(workDir, "page_"+strconv.Itoa(p)+".pdf")
+		if err := writePages(input, single, []pageRange{{p, p}}); err != nil {
+			return nil, err
+		}
+		pageSizes[p] = fileSize(single)
+	}
+
+	// 2. Group pages by the estimate and confirm the real size
+	// the number of parts is only known at the end; there are never more
+	// than pages
+	base := TempName("split", "")
+	digits := partDigits(total)
+	var outputs []string
+
+	fail := func(err error) ([]string, error) {
+		for _, o := range outputs {
+			DeleteFile(o)
+		}
+		return nil, err
+	}
+
+	from := 1
+	for from <= total {
+		write := func(to int) (string, error) {
+			out := fmt.Sprintf("%s_%0*d_pages_%d-%d.pdf", base, digits, len(outputs)+1, from, to)
+			if err := writePages(input, out, []pageRange{{from, to}}); err != nil {
+				return "", err
+			}
+			return out, nil
+		}
+
+		to := from
+		sum := pageSizes[from]
+		for to < total && sum+pageSizes[to+1] <= maxBytes {
+			to++
+			sum += pageSizes[to]
+		}
+
+		out, err := write(to)
+		if err != nil {
+			return fail(err)
+		}
+
+		if fileSize(out) > maxBytes && to > from {
+			DeleteFile(out)
+
+			// from..lo fits (or is a single page), from..hi+1 does not
+			lo, hi := from, to-1
+			out = ""
+			for lo < hi {
+				mid := (lo + hi + 1) / 2
+				try, err := write(mid)
+				if err != nil {
+					DeleteFile(out)
+					return fail(err)
+				}
+				if fileSize(try) <= maxBytes {
+					DeleteFile(out)
+					out, lo = try, mid
+				} else {
+					DeleteFile(try)
+					hi = mid - 1
+				}
+			}
+
+			to = lo
+			if out == "" {
+				if out, err = write(to); err != nil {
+					return fail(err)
+				}
+			}
+		}
+
+		if fileSize(out) > maxBytes {
+			log.Println("⚠️ Page", from, "alone exceeds the size limit")
+		}
+		outputs = append(outputs, out)
+		from = to + 1
+	}
+
+	return outputs, nil
+}
+
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
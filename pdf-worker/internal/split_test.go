@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartDigits(t *testing.T) {
+	tests := []struct {
+		parts int
+		want  int
+	}{
+		{1, 2},
+		{9, 2},
+		{10, 2},
+		{99, 2},
+		{100, 3},
+		{999, 3},
+		{1000, 4},
+	}
+
+	for _, tt := range tests {
+		if got := partDigits(tt.parts); got != tt.want {
+			t.Errorf("partDigits(%d) = %d, want %d", tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestSplitEvery(t *testing.T) {
+	tests := []struct {
+		n, total int
+		want     []pageRange
+	}{
+		{1, 3, []pageRange{{1, 1}, {2, 2}, {3, 3}}},
+		{2, 5, []pageRange{{1, 2}, {3, 4}, {5, 5}}},
+		{3, 6, []pageRange{{1, 3}, {4, 6}}},
+		{10, 4, []pageRange{{1, 4}}},
+		{4, 4, []pageRange{{1, 4}}},
+	}
+
+	for _, tt := range tests {
+		got, err := splitEvery(tt.n, tt.total)
+		if err != nil {
+			t.Errorf("splitEvery(%d, %d): %v", tt.n, tt.total, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitEvery(%d, %d) = %v, want %v", tt.n, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestSplitEveryInvalid(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		if _, err := splitEvery(n, 5); err == nil {
+			t.Errorf("splitEvery(%d, 5): expected an error", n)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package internal
+
+// ----------------------------
+// MYERS DIFF
+// ----------------------------
+// Shortest edit script between two token lists. Only the diagonals that
+// were reachable are kept per step, so memory grows with the square of
+// the number of edits, not with the input size. Past maxEdits the rest
+// is reported as one big replace instead.
+
+type diffKind byte
+
+const (
+	diffEqual  diffKind = '='
+	diffDelete diffKind = '-'
+	diffInsert diffKind = '+'
+)
+
+// A and B are token indices in the old and new list (-1 when n/a)
+type diffOp struct {
+	Kind diffKind
+	A, B int
+}
+
+const maxEdits = 4000
+
+func diffTokens(a, b []string) []diffOp {
+	// common prefix and suffix are cheap and usually most of a document
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	var ops []diffOp
+	for i := 0; i < pre; i++ {
+		ops = append(ops, diffOp{diffEqual, i, i})
+	}
+
+	for _, op := range myers(a[pre:len(a)-suf], b[pre:len(b)-suf]) {
+		if op.A >= 0 {
+			op.A += pre
+		}
+		if op.B >= 0 {
+			op.B += pre
+		}
+		ops = append(ops, op)
+	}
+
+	for i := 0; i < suf; i++ {
+		ops = append(ops, diffOp{diffEqual, len(a) - suf + i, len(b) - suf + i})
+	}
+
+	return ops
+}
+
+func myers(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+
+	// trace[d][k+d] = furthest x on diagonal k before step d
+	var trace [][]int
+	found := false
+
+	for d := 0; d <= max && d <= maxEdits; d++ {
+		snap := make([]int, 2*d+1)
+		copy(snap, v[off-d:off+d+1])
+		trace = append(trace, snap)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+
+	if !found {
+		return replaceAll(n, m)
+	}
+
+	// walk back from (n, m)
+	var rev []diffOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d] }
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			rev = append(rev, diffOp{diffEqual, x - 1, y - 1})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				rev = append(rev, diffOp{diffInsert, -1, y - 1})
+			} else {
+				rev = append(rev, diffOp{diffDelete, x - 1, -1})
+			}
+		}
+
+		x, y = prevX, prevY
+	}
+
+	ops := make([]diffOp, len(rev))
+	for i := range rev {
+		ops[i] = rev[len(rev)-1-i]
+	}
+	return ops
+}
+
+func replaceAll(n, m int) []diffOp {
+	var ops []diffOp
+	for i := 0; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, i, -1})
+	}
+	for j := 0; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, -1, j})
+	}
+	return ops
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+// Checks that ops walk both lists in order and only pair equal tokens;
+// returns the number of inserts and deletes.
+func checkDiff(t *testing.T, a, b []string, ops []diffOp) int {
+	t.Helper()
+
+	i, j, edits := 0, 0, 0
+	for _, op := range ops {
+		switch op.Kind {
+		case diffEqual:
+			if op.A != i || op.B != j {
+				t.Fatalf("equal op %v out of order, expected (%d, %d)", op, i, j)
+			}
+			if a[op.A] != b[op.B] {
+				t.Fatalf("equal op %v pairs %q with %q", op, a[op.A], b[op.B])
+			}
+			i++
+			j++
+		case diffDelete:
+			if op.A != i || op.B != -1 {
+				t.Fatalf("delete op %v out of order, expected A=%d", op, i)
+			}
+			i++
+			edits++
+		case diffInsert:
+			if op.B != j || op.A != -1 {
+				t.Fatalf("insert op %v out of order, expected B=%d", op, j)
+			}
+			j++
+			edits++
+		default:
+			t.Fatalf("unknown op kind %q", op.Kind)
+		}
+	}
+
+	if i != len(a) || j != len(b) {
+		t.Fatalf("ops cover %d/%d old and %d/%d new tokens", i, len(a), j, len(b))
+	}
+	return edits
+}
+
+func TestDiffTokens(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"", "", 0},
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c", "a c", 1},
+		{"a c", "a b c", 1},
+		{"a b c", "a x c", 2},
+		{"a b c d", "b c d e", 2},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"the quick brown fox", "the slow brown dog", 4},
+		{"x y z", "a b c", 6},
+		{"a a a", "a a", 1},
+		{"a b a b", "b a b a", 2},
+	}
+
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		ops := diffTokens(a, b)
+		if edits := checkDiff(t, a, b, ops); edits != tt.edits {
+			t.Errorf("diffTokens(%q, %q): %d edits, want %d", tt.a, tt.b, edits, tt.edits)
+		}
+	}
+}
+
+func TestMyersWithoutPrefixTrim(t *testing.T) {
+	// myers on its own must find the same shortest scripts
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"a b c", "a b c", 0},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"a b c d e", "a x c y e", 4},
+	}
+
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		if edits := checkDiff(t, a, b, myers(a, b)); edits != tt.edits {
+			t.Errorf("myers(%q, %q): %d edits, want %d", tt.a, tt.b, edits, tt.edits)
+		}
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	a, b := []string{"a", "b"}, []string{"x", "y", "z"}
+	if edits := checkDiff(t, a, b, replaceAll(len(a), len(b))); edits != 5 {
+		t.Errorf("replaceAll(2, 3): %d edits, want 5", edits)
+	}
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ----------------------------
+// TEXT WITH POSITIONS (pdftotext -bbox-layout)
+// ----------------------------
+// Coordinates are poppler's: points, origin at the top left of the page
+// as displayed.
+type layoutWord struct {
+	XMin float64 `xml:"xMin,attr"`
+	YMin float64 `xml:"yMin,attr"`
+	XMax float64 `xml:"xMax,attr"`
+	YMax float64 `xml:"yMax,attr"`
+	Text string  `xml:",chardata"`
+}
+
+type layoutLine struct {
+	Words []layoutWord `xml:"word"`
+}
+
+type layoutPage struct {
+	Width  float64      `xml:"width,attr"`
+	Height float64      `xml:"height,attr"`
+	Lines  []layoutLine `xml:"flow>block>line"`
+}
+
+// Words of the line joined by single spaces, with the byte offset where
+// each word starts, so regex matches can be mapped back to words.
+func (l layoutLine) text() (string, []int) {
+	var b strings.Builder
+	var starts []int
+
+	for i, w := range l.Words {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		starts = append(starts, b.Len())
+		b.WriteString(w.Text)
+	}
+
+	return b.String(), starts
+}
+
+// Plain text of the page, one line per layout line
+func (p layoutPage) text() string {
+	var lines []string
+	for _, l := range p.Lines {
+		s, _ := l.text()
+		lines = append(lines, s)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func textLayout(input string) ([]layoutPage, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("pdftotext", "-bbox-layout", input, "-")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("pdftotext failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var doc struct {
+		Pages []layoutPage `xml:"body>doc>page"`
+	}
+
+	dec := xml.NewDecoder(&stdout)
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("unreadable pdftotext output: %w", err)
+	}
+
+	return doc.Pages, nil
+}
@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Paper sizes in PDF points, keyed by the names pdfcpu uses
+var paperSizes = map[string][2]float64{
+	"A4":     {595.28, 841.89},
+	"Letter": {612, 792},
+}
+
+// Case-insensitive lookup of a supported paper size name
+func paperName(s string) (string, bool) {
+	for name := range paperSizes {
+		if strings.EqualFold(name, s) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// one line of generated text; X is the offset from the left margin and
+// Right is an optional second column (e.g. a page number)
+type textLine struct {
+	Text  string
+	Right string
+	X     float64
+	Size  float64
+}
+
+// ----------------------------
+// MINIMAL TEXT PDF WRITER
+// ----------------------------
+// Writes plain Helvetica text pages without any external tool. Used for
+// generated pages (table of contents, reports) that get merged into a
+// real document afterwards.
+func writeTextPDF(out string, pages [][]textLine, width, height float64) error {
+	var buf bytes.Buffer
+	var offsets []int
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 1 catalog, 2 pages, 3 font, then page/content pairs
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+
+	var kids []string
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*i))
+	}
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	const margin, rightColumn = 56.0, 40.0
+
+	for i, lines := range pages {
+		var content bytes.Buffer
+		y := height - margin
+
+		for _, l := range lines {
+			size := l.Size
+			if size == 0 {
+				size = 11
+			}
+			y -= size * 1.6
+
+			// long text is cut short instead of running off the page or
+			// into the right column
+			room := width - 2*margin - l.X
+			if l.Right != "" {
+				room -= rightColumn + 12
+			}
+			fmt.Fprintf(&content, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+				size, margin+l.X, y, pdfString(fitText(l.Text, size, room)))
+
+			if l.Right != "" {
+				fmt.Fprintf(&content, "BT /F1 %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+					size, width-margin-rightColumn, y, pdfString(l.Right))
+			}
+		}
+
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] "+
+			"/Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			width, height, 5+2*i))
+
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return os.WriteFile(out, buf.Bytes(), 0644)
+}
+
+// Helvetica advance widths (1/1000 em) for ASCII 32-126, from the
+// standard AFM; anything else is measured as a wide letter.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func textWidth(s string, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			w += helveticaWidths[r-32]
+		} else {
+			w += 722
+		}
+	}
+	return float64(w) * size / 1000
+}
+
+// Shortens s with an ellipsis until it fits in maxWidth points
+func fitText(s string, size, maxWidth float64) string {
+	if textWidth(s, size) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"…", size) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "…"
+}
+
+// Escapes text for a PDF literal string in WinAnsi encoding; characters
+// Helvetica can't show become "?"
+func pdfString(s string) string {
+	var b strings.Builder
+	enc := charmap.Windows1252.NewEncoder()
+
+	for _, r := range s {
+		switch r {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+			continue
+		}
+
+		if r < 0x80 {
+			if r < 0x20 {
+				r = ' '
+			}
+			b.WriteRune(r)
+			continue
+		}
+
+		encoded, err := enc.String(string(r))
+		if err != nil || len(encoded) != 1 {
+			b.WriteByte('?')
+			continue
+		}
+		fmt.Fprintf(&b, "\\%03o", encoded[0])
+	}
+
+	return b.String()
+}
@@ -1,15 +1,44 @@
 package internal
 
 import (
+	"crypto/sha256"
+	"io"
 	"os"
 	"path/filepath"
 	"time"
 )
 
 func TempName(prefix, ext string) string {
-	return filepath.Join("/tmp", prefix+"_"+time.Now().Format("150405")+ext)
+	return filepath.Join("/tmp", prefix+"_"+time.Now().Format("150405.000000")+ext)
 }
 
 func DeleteFile(path string) {
 	os.Remove(path)
 }
+
+func fileHash(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// True when both files have the same bytes, e.g. one upload picked twice
+func sameContent(a, b string) (bool, error) {
+	ha, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := fileHash(b)
+	if err != nil {
+		return false, err
+	}
+	return string(ha) == string(hb), nil
+}